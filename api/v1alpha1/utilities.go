@@ -10,6 +10,7 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -28,6 +29,27 @@ func Unescape(text string) string {
 	return text
 }
 
+// Escapes a path
+// Replaces \ with \\ and : with \: (the inverse of Unescape)
+func Escape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	text = strings.ReplaceAll(text, ":", "\\:")
+
+	return text
+}
+
+// JoinSourcePathAndTargetName is the inverse of SplitPathToSourcePathAndTargetName, it returns
+// $sourcePath[:$targetName] with the character ':' escaped as the string "\:"
+func JoinSourcePathAndTargetName(sourcePath string, targetName *string) string {
+	path := Escape(sourcePath)
+
+	if targetName != nil && *targetName != "" {
+		path += ":" + Escape(*targetName)
+	}
+
+	return path
+}
+
 // SplitPathToSourcePathAndTargetName splits its operand to 2 strings (second can be nil)
 // The format of @path is $sourcePath[:$targetName] where the character ':' is escaped
 // as the string "\:". If there is no $targetName then ret.TargetName is nil
@@ -56,3 +78,77 @@ func SplitPathToSourcePathAndTargetName(path string) SourcePathToTargetFileName
 
 	return ret
 }
+
+// ToArgs renders the orchestrator options as command-line arguments to elaunch.py
+func (o *OrchestratorOptions) ToArgs() []string {
+	args := []string{}
+
+	if o == nil {
+		return args
+	}
+
+	if o.Platform != "" {
+		args = append(args, "--platform="+o.Platform)
+	}
+	if o.LogLevel != nil {
+		args = append(args, fmt.Sprintf("--log-level=%d", *o.LogLevel))
+	}
+	if o.RestartFromStage != nil {
+		args = append(args, fmt.Sprintf("--restart=%d", *o.RestartFromStage))
+	}
+	if o.DiscovererMonitorDir != "" {
+		args = append(args, "--discovererMonitorDir="+o.DiscovererMonitorDir)
+	}
+	if o.ExecutionMode != "" {
+		args = append(args, "--executionMode="+o.ExecutionMode)
+	}
+
+	return args
+}
+
+// OptionName returns the name of a command-line argument e.g. "--log-level" for "--log-level=15"
+func OptionName(arg string) string {
+	return strings.SplitN(arg, "=", 2)[0]
+}
+
+// ParseInputFile converts an entry of spec.inputs to its structured form
+func ParseInputFile(path string, source InputFileSource) InputFile {
+	complex := SplitPathToSourcePathAndTargetName(path)
+	ret := InputFile{Path: complex.SourcePath, Source: source}
+
+	if complex.TargetName != nil {
+		ret.Rename = *complex.TargetName
+	}
+
+	return ret
+}
+
+// String converts an InputFile to the $sourcePath[:$targetName] format of spec.inputs.
+// The format does not record the source, nor the contents of inline files
+func (f *InputFile) String() string {
+	var rename *string
+
+	if f.Rename != "" {
+		rename = &f.Rename
+	}
+
+	return JoinSourcePathAndTargetName(f.Path, rename)
+}
+
+// Succeeded returns true if the orchestrator finished and reported success
+func (s *WorkflowStatus) Succeeded() bool {
+	return s.Phase == WorkflowSucceeded ||
+		(s.Experimentstate == ExperimentStateFinished && s.Exitstatus == ExitStatusSuccess)
+}
+
+// Failed returns true if the orchestrator terminated without reporting success, or if the
+// Workflow cannot start, or if it was cancelled
+func (s *WorkflowStatus) Failed() bool {
+	return s.Phase == WorkflowFailed || s.Phase == WorkflowCancelled || s.Experimentstate == ExperimentStateFailed ||
+		(s.Experimentstate == ExperimentStateFinished && s.Exitstatus != ExitStatusSuccess)
+}
+
+// Completed returns true if the Workflow will not make any more progress
+func (s *WorkflowStatus) Completed() bool {
+	return s.Succeeded() || s.Failed()
+}
@@ -55,3 +55,62 @@ func TestSplitPathToSourcePathAndTargetName(t *testing.T) {
 		}
 	}
 }
+
+// TestOrchestratorOptionsToArgs tests that OrchestratorOptions are rendered to elaunch.py arguments
+func TestOrchestratorOptionsToArgs(t *testing.T) {
+	logLevel := int32(15)
+	restart := int32(0)
+
+	options := OrchestratorOptions{
+		Platform:             "openshift",
+		LogLevel:             &logLevel,
+		RestartFromStage:     &restart,
+		DiscovererMonitorDir: "/tmp/workdir/pod-reporter/update-files",
+		ExecutionMode:        "production",
+	}
+
+	expected := []string{
+		"--platform=openshift",
+		"--log-level=15",
+		"--restart=0",
+		"--discovererMonitorDir=/tmp/workdir/pod-reporter/update-files",
+		"--executionMode=production",
+	}
+
+	actual := options.ToArgs()
+	if len(actual) != len(expected) {
+		t.Fatal("Invalid arguments", "actual", actual, "expected", expected)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Error("Invalid argument", "actual", actual[i], "expected", expected[i])
+		}
+	}
+
+	var empty *OrchestratorOptions
+	if len(empty.ToArgs()) != 0 {
+		t.Error("Expected no arguments for nil OrchestratorOptions")
+	}
+}
+
+// TestInputFileRoundTrip tests that InputFile converts to and from the $sourcePath[:$targetName] format
+func TestInputFileRoundTrip(t *testing.T) {
+	tests := map[string]InputFile{
+		"/hello/world":                {Path: "/hello/world"},
+		"/hello/world:other":          {Path: "/hello/world", Rename: "other"},
+		"/hello\\:world:other\\:name": {Path: "/hello:world", Rename: "other:name"},
+		"relative/path":               {Path: "relative/path"},
+	}
+
+	for path, expected := range tests {
+		actual := ParseInputFile(path, InputFileSourceVolume)
+		if actual.Path != expected.Path || actual.Rename != expected.Rename ||
+			actual.Source != InputFileSourceVolume {
+			t.Error("Invalid InputFile", "path", path, "actual", actual, "expected", expected)
+		}
+
+		if actual.String() != path {
+			t.Error("Invalid String()", "actual", actual.String(), "expected", path)
+		}
+	}
+}
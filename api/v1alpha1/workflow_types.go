@@ -12,10 +12,20 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // WorkflowSpec defines the desired state of Workflow
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.restartFromStage) || has(self.instance)",message="spec.orchestratorOptions.restartFromStage requires spec.instance"
+// +kubebuilder:validation:XValidation:rule="!has(self.restartFrom) || (!has(self.package) && !has(self.instance))",message="spec.restartFrom is mutually exclusive with spec.package and spec.instance"
+// +kubebuilder:validation:XValidation:rule="!has(self.inputFiles) || has(self.s3BucketInput) || !self.inputFiles.exists(f, has(f.source) && f.source == 's3')",message="spec.inputFiles with source s3 require spec.s3BucketInput"
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.platform) || !has(self.additionalOptions) || !self.additionalOptions.exists(o, o == '--platform' || o.startsWith('--platform='))",message="spec.additionalOptions must not repeat --platform which spec.orchestratorOptions.platform sets"
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.logLevel) || !has(self.additionalOptions) || !self.additionalOptions.exists(o, o == '--log-level' || o.startsWith('--log-level='))",message="spec.additionalOptions must not repeat --log-level which spec.orchestratorOptions.logLevel sets"
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.restartFromStage) || !has(self.additionalOptions) || !self.additionalOptions.exists(o, o == '--restart' || o.startsWith('--restart='))",message="spec.additionalOptions must not repeat --restart which spec.orchestratorOptions.restartFromStage sets"
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.discovererMonitorDir) || !has(self.additionalOptions) || !self.additionalOptions.exists(o, o == '--discovererMonitorDir' || o.startsWith('--discovererMonitorDir='))",message="spec.additionalOptions must not repeat --discovererMonitorDir which spec.orchestratorOptions.discovererMonitorDir sets"
+// +kubebuilder:validation:XValidation:rule="!has(self.orchestratorOptions) || !has(self.orchestratorOptions.executionMode) || !has(self.additionalOptions) || !self.additionalOptions.exists(o, o == '--executionMode' || o.startsWith('--executionMode='))",message="spec.additionalOptions must not repeat --executionMode which spec.orchestratorOptions.executionMode sets"
 type WorkflowSpec struct {
 	// Image of workflow scheduler, leave blank to fill in with default option
 	Image string `json:"image,omitempty"`
@@ -28,6 +38,10 @@ type WorkflowSpec struct {
 	Package  *Gitrepo `json:"package,omitempty"`
 	Instance string   `json:"instance,omitempty"`
 
+	// Restart the instance directory of a previous Workflow, mutually exclusive with package and instance
+	// +optional
+	RestartFrom *RestartFrom `json:"restartFrom,omitempty"`
+
 	Debug bool `json:"debug,omitempty"`
 
 	//if empty elaunch.py
@@ -49,18 +63,33 @@ type WorkflowSpec struct {
 	// to entries of Volumes and VolumeMounts)
 	// +optional
 	InputDataVolume *v1.Volume `json:"inputDataVolume,omitempty"`
-	// Absolute paths to input files, files can reside in volumes
+	// Absolute paths to input files, files can reside in volumes. Paths have the format
+	// $sourcePath[:$targetName] where ':' is escaped as "\:". Relative paths are rebased to /tmp/inputdir
+	// or to the input folder of spec.s3BucketInput (if set).
 	// +optional
 	Inputs []string `json:"inputs,omitempty"`
 
-	// Absolute paths to variable files (currently support just 1 file). Variable file can reside in a volume.
+	// Input files in structured form, an alternative to the escaped paths of spec.inputs
+	// +kubebuilder:validation:MaxItems=256
+	// +optional
+	InputFiles []InputFile `json:"inputFiles,omitempty"`
+
+	// Absolute paths to variable files. Variable files can reside in a volume. When there are multiple
+	// files they are merged in order (later files override earlier ones) into a single file for the orchestrator.
 	// +optional
 	Variables []string `json:"variables,omitempty"`
 	// Absolute paths to data files, files can reside in volumes. Identically named files are expected to already exist in the workflow package definition
 	// +optional
 	Data []string `json:"data,omitempty"`
 
-	// Additional command-line arguments to orchestrator (e.g. ["--platform=openshift", "--log-level=15", "--discovererMonitorDir=/tmp/workdir/pod-reporter/update-files"]
+	// Typed command-line arguments to the orchestrator, prefer these over additionalOptions
+	// +optional
+	OrchestratorOptions *OrchestratorOptions `json:"orchestratorOptions,omitempty"`
+
+	// Additional command-line arguments to orchestrator (e.g. ["--failSafeDelays=no"]). They are appended
+	// after the ones generated for orchestratorOptions and must not repeat them.
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MaxLength=1024
 	// +optional
 	AdditionalOptions []string `json:"additionalOptions,omitempty"`
 
@@ -72,8 +101,87 @@ type WorkflowSpec struct {
 	Env []v1.EnvVar `json:"env,omitempty"`
 
 	// Information for fetching inputs from a S3 bucket
-	S3BucketInput     *DatashimS3BucketInfo `json:"s3BucketInput,omitempty"`
-	S3FetchFilesImage string                `json:"s3FetchFilesImage,omitempty"`
+	S3BucketInput     *S3BucketInputInfo `json:"s3BucketInput,omitempty"`
+	S3FetchFilesImage string             `json:"s3FetchFilesImage,omitempty"`
+
+	// Workflows that must complete before the operator creates the pod of this Workflow
+	// +optional
+	DependsOn []WorkflowDependency `json:"dependsOn,omitempty"`
+
+	// Information for uploading outputs to a S3 bucket after the orchestrator finishes successfully
+	// +optional
+	S3BucketOutput *S3BucketOutputInfo `json:"s3BucketOutput,omitempty"`
+	// Image which uploads the outputs to the S3 bucket, leave blank to fill in with default option
+	// +optional
+	S3UploadFilesImage string `json:"s3UploadFilesImage,omitempty"`
+
+	// Set to true to stop the workflow. The orchestrator receives SIGTERM and has
+	// terminationGracePeriodSeconds to shut down gracefully before the pod is terminated
+	// +optional
+	Cancel bool `json:"cancel,omitempty"`
+	// Set to true to stop the workflow without cancelling it, like the suspend field of batch/v1 Jobs.
+	// The operator does not create the pod of a suspended Workflow. When a running Workflow
+	// resumes, the orchestrator restarts from the instance directory of the previous run
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Maximum duration of the primary pod (e.g. 48h). When it expires the orchestrator receives SIGTERM
+	// and the Workflow fails with the reason DeadlineExceeded
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Maximum duration of fetching the workflow package (e.g. 10m)
+	// +optional
+	PackageFetchTimeout *metav1.Duration `json:"packageFetchTimeout,omitempty"`
+	// Seconds after the Workflow finishes (succeeds, fails, or is cancelled) that the operator cleans
+	// it up, leave blank to fill in with the default option. 0 means clean up immediately
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// What to clean up when ttlSecondsAfterFinished expires. Workflow deletes the Workflow object along
+	// with its pods and ConfigMap, Pods deletes just the pods so that the status remains queryable
+	// +kubebuilder:validation:Enum=Workflow;Pods
+	// +kubebuilder:default=Workflow
+	// +optional
+	TTLCleanup TTLCleanup `json:"ttlCleanup,omitempty"`
+	// Recreate the primary pod when it fails because of the infrastructure
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// Set to true to review the Workflow before it starts. The operator resolves the default values,
+	// runs preflight checks, and renders the primary pod in the <name>-config ConfigMap but does not
+	// create the pod until hold is false
+	// +optional
+	Hold bool `json:"hold,omitempty"`
+	// Seconds that the orchestrator has to shut down gracefully, defaults to 600
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Order of the Workflow in the admission queue of its namespace, higher values start first.
+	// Defaults to the value of spec.priorityClassName or else 0
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+	// PriorityClass of the primary pod, also the default of spec.priority
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Constraints for scheduling the primary pod, these default to the namespace-level values in the
+	// config.json of the st4sd-runtime-service ConfigMap
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// +optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// +optional
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+	// Labels, annotations, and a partial PodSpec that the operator strategic-merge-patches onto
+	// the primary pod. Containers are matched by name e.g. elaunch-primary, monitor-elaunch-container,
+	// git-sync-package, s3-fetch
+	// +optional
+	PodTemplate *PodTemplateOverlay `json:"podTemplate,omitempty"`
+	// Kueue LocalQueue that admits the primary pod. The pod starts suspended and the Workflow
+	// remains Queued until Kueue admits it, the operator's admission queue does not apply
+	// +optional
+	QueueName string `json:"queueName,omitempty"`
 }
 
 type DatashimS3BucketInfo struct {
@@ -81,6 +189,61 @@ type DatashimS3BucketInfo struct {
 	S3BucketInfo `json:"bucketInfo,omitempty"`
 }
 
+// S3BucketInputInfo describes the S3 bucket to fetch input and data files from
+type S3BucketInputInfo struct {
+	DatashimS3BucketInfo `json:",inline"`
+
+	// Objects to fetch in addition to the ones that spec.inputs, spec.inputFiles, and spec.data reference.
+	// The object with the key $key is stored under /tmp/s3-root-dir/$folder/$key
+	// +optional
+	Objects []S3ObjectSelector `json:"objects,omitempty"`
+
+	// Maximum total size of the objects in spec.s3BucketInput.objects, the fetch fails if they are larger
+	// +optional
+	MaxTotalSize *resource.Quantity `json:"maxTotalSize,omitempty"`
+}
+
+// S3ObjectSelector selects objects in a S3 bucket
+// +kubebuilder:validation:XValidation:rule="has(self.prefix) || has(self.glob)",message="at least one of prefix and glob is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.sha256) || !has(self.glob)",message="sha256 requires a prefix that is the key of exactly one object"
+type S3ObjectSelector struct {
+	// Selects the objects whose key starts with prefix
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Selects the objects whose key matches the glob (e.g. "molecules/*.csv"), * also matches /
+	// +optional
+	Glob string `json:"glob,omitempty"`
+
+	// Folder under /tmp/s3-root-dir to store the objects in, one of input, data
+	// +kubebuilder:validation:Enum=input;data
+	// +kubebuilder:default=input
+	// +optional
+	Folder string `json:"folder,omitempty"`
+
+	// Verify that the MD5 checksum of each object matches its ETag (skipped for multipart uploads)
+	// +optional
+	VerifyETag bool `json:"verifyETag,omitempty"`
+
+	// Expected sha256 checksum (hex) of the object, requires that the selector matches exactly one object
+	// +kubebuilder:validation:Pattern=`^[a-fA-F0-9]{64}$`
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// S3BucketOutputInfo describes which outputs to upload to a S3 bucket
+type S3BucketOutputInfo struct {
+	DatashimS3BucketInfo `json:",inline"`
+
+	// Prefix of the object keys, defaults to the name of the Workflow
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Names of key-outputs or glob patterns (relative to the instance directory) of the files to upload
+	// +kubebuilder:validation:MinItems=1
+	Outputs []string `json:"outputs"`
+}
+
 type S3BucketInfo struct {
 	AccessKeyID     S3InputVariable `json:"accessKeyID,omitempty"`
 	SecretAccessKey S3InputVariable `json:"secretAccessKey,omitempty"`
@@ -106,6 +269,122 @@ type S3InputVariable struct {
 	ValueFrom *v1.EnvVarSource `json:"valueFrom,omitempty" protobuf:"bytes,3,opt,name=valueFrom"`
 }
 
+// InputFileSource is the location of an input file
+type InputFileSource string
+
+const (
+	// InputFileSourceVolume is a file in a volume that the primary pod mounts
+	InputFileSourceVolume InputFileSource = "volume"
+	// InputFileSourceS3 is an object in the bucket of spec.s3BucketInput
+	InputFileSourceS3 InputFileSource = "s3"
+	// InputFileSourceInline is a file whose contents are part of the Workflow object
+	InputFileSourceInline InputFileSource = "inline"
+	// InputFileSourceWorkflow is a key-output of another Workflow
+	InputFileSourceWorkflow InputFileSource = "workflow"
+)
+
+// InputFile is the structured form of an entry in spec.inputs
+// +kubebuilder:validation:XValidation:rule="(has(self.source) && self.source == 'inline') == has(self.content)",message="content is required for, and only valid with, source inline"
+// +kubebuilder:validation:XValidation:rule="has(self.path) != has(self.fromWorkflow)",message="exactly one of path and fromWorkflow is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.fromWorkflow) || !has(self.source) || self.source == 'workflow'",message="fromWorkflow is only valid with source workflow"
+// +kubebuilder:validation:XValidation:rule="!has(self.source) || self.source != 'inline' || !has(self.path) || (!self.path.contains('/') && self.path != '.' && self.path != '..')",message="path must be a file name for source inline"
+// +k8s:openapi-gen=true
+type InputFile struct {
+	// For source=volume (default), an absolute path or a path relative to /tmp/inputdir.
+	// For source=s3, the key of the object in the bucket of spec.s3BucketInput.
+	// For source=inline, the name of the file.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Name of the input file that the workflow expects, defaults to the name of the file in path
+	// +optional
+	Rename string `json:"rename,omitempty"`
+
+	// Location of the file, one of volume, s3, inline, workflow. Entries with fromWorkflow default to workflow
+	// all other entries default to volume
+	// +kubebuilder:validation:Enum=volume;s3;inline;workflow
+	// +optional
+	Source InputFileSource `json:"source,omitempty"`
+
+	// Contents of the file when source is inline
+	// +optional
+	Content string `json:"content,omitempty"`
+
+	// Key-output of another Workflow in the same namespace. The Workflow must share the working volume
+	// of this one. The operator creates the pod of this Workflow after the other one succeeds.
+	// +optional
+	FromWorkflow *WorkflowOutputReference `json:"fromWorkflow,omitempty"`
+}
+
+// DependencyCondition is the condition that a Workflow dependency must meet
+type DependencyCondition string
+
+const (
+	// DependencySucceeded requires the dependency to succeed, the dependent Workflow fails if it does not
+	DependencySucceeded DependencyCondition = "Succeeded"
+	// DependencyCompleted requires the dependency to terminate, successfully or not
+	DependencyCompleted DependencyCondition = "Completed"
+)
+
+// WorkflowDependency is a Workflow in the same namespace which must meet a condition
+// +k8s:openapi-gen=true
+type WorkflowDependency struct {
+	// Name of the Workflow
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Condition that the Workflow must meet, one of Succeeded, Completed
+	// +kubebuilder:validation:Enum=Succeeded;Completed
+	// +kubebuilder:default=Succeeded
+	// +optional
+	Condition DependencyCondition `json:"condition,omitempty"`
+}
+
+// WorkflowOutputReference references a key-output of a Workflow
+// +k8s:openapi-gen=true
+type WorkflowOutputReference struct {
+	// Name of the Workflow
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Name of the key-output
+	// +kubebuilder:validation:MinLength=1
+	Output string `json:"output"`
+}
+
+// OrchestratorOptions holds the command-line arguments of the orchestrator (elaunch.py) that the
+// operator knows how to validate and render
+// +k8s:openapi-gen=true
+type OrchestratorOptions struct {
+	// Name of the platform, defined in the workflow definition, to execute (--platform)
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_.-]+$`
+	// +optional
+	Platform string `json:"platform,omitempty"`
+
+	// Verbosity of the orchestrator using the python logging levels e.g. 10 for debug, 15 for
+	// verbose info, 20 for info (--log-level)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=50
+	// +optional
+	LogLevel *int32 `json:"logLevel,omitempty"`
+
+	// Index of the stage to restart spec.instance from (--restart)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RestartFromStage *int32 `json:"restartFromStage,omitempty"`
+
+	// Directory that the orchestrator monitors for updates on the state of the pods it creates
+	// (--discovererMonitorDir)
+	// +optional
+	DiscovererMonitorDir string `json:"discovererMonitorDir,omitempty"`
+
+	// Execution mode of the workflow (--executionMode)
+	// +kubebuilder:validation:Enum=development;testing;production
+	// +optional
+	ExecutionMode string `json:"executionMode,omitempty"`
+}
+
 // +k8s:openapi-gen=true
 type Gitrepo struct {
 	URL           string        `json:"url,omitempty"`
@@ -121,13 +400,28 @@ type Gitrepo struct {
 
 // +k8s:openapi-gen=true
 type Resourcedefinition struct {
-	Cpu    string `json:"cpu,omitempty"`
-	Memory string `json:"memory,omitempty"`
+	// Shorthands that set both the request and the limit of a resource, unless requests or limits is set
+	// in which case they only fill in missing requests
+	Cpu              string `json:"cpu,omitempty"`
+	Memory           string `json:"memory,omitempty"`
+	EphemeralStorage string `json:"ephemeralStorage,omitempty"`
+
+	// Requests of the container, including extended resources
+	// +optional
+	Requests v1.ResourceList `json:"requests,omitempty"`
+	// Limits of the container, including extended resources. Resources without a limit are unbounded
+	// +optional
+	Limits v1.ResourceList `json:"limits,omitempty"`
 }
 
 // Defines Resource requests for containers in the primary pod of a workflow
 // +k8s:openapi-gen=true
 type Resourcespec struct {
+	// Name of a resource profile in the config.json of the st4sd-runtime-service ConfigMap, the
+	// resources of the containers below override those of the profile
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
 	// Resource request for workflow orchestrator container
 	// +optional
 	ElaunchPrimary *Resourcedefinition `json:"elaunchPrimary,omitempty"`
@@ -136,14 +430,192 @@ type Resourcespec struct {
 	// +optional
 	Monitor *Resourcedefinition `json:"monitor,omitempty"`
 
-	// Resource request for git-clone init-container (deprecated)
+	// Resource request for the init-containers that fetch the workflow package (deprecated)
 	// +optional
 	GitFetch *Resourcedefinition `json:"gitFetch,omitempty"`
+
+	// Resource request for the init-containers that fetch spec.s3BucketInput and the pod that uploads
+	// spec.s3BucketOutput
+	// +optional
+	S3Fetch *Resourcedefinition `json:"s3Fetch,omitempty"`
+}
+
+// PodTemplateMetadata holds the labels and annotations to add to the primary pod of a Workflow
+// +k8s:openapi-gen=true
+type PodTemplateMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PodTemplateOverlay customizes the primary pod of a Workflow
+// +k8s:openapi-gen=true
+type PodTemplateOverlay struct {
+	// +optional
+	Metadata PodTemplateMetadata `json:"metadata,omitempty"`
+	// A partial PodSpec in the strategic-merge-patch format
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Spec *runtime.RawExtension `json:"spec,omitempty"`
 }
 
+// RetryReason is an infrastructure failure of the primary pod of a Workflow
+// +kubebuilder:validation:Enum=PackageFetchFailed;Evicted;OOMKilled;NodeLost
+type RetryReason string
+
+const (
+	// RetryPackageFetchFailed is an init-container that fetches the workflow package exiting with an error
+	RetryPackageFetchFailed RetryReason = "PackageFetchFailed"
+	// RetryEvicted is the kubelet evicting the pod
+	RetryEvicted RetryReason = "Evicted"
+	// RetryOOMKilled is a container of the pod running out of memory
+	RetryOOMKilled RetryReason = "OOMKilled"
+	// RetryNodeLost is the node of the pod becoming unreachable
+	RetryNodeLost RetryReason = "NodeLost"
+)
+
+// RetryPolicy describes when and how many times to recreate the primary pod of a Workflow
+// +k8s:openapi-gen=true
+type RetryPolicy struct {
+	// Maximum number of times to recreate the primary pod
+	// +kubebuilder:validation:Minimum=0
+	Limit int32 `json:"limit"`
+	// Delay before recreating the pod, it doubles after each retry. Defaults to 10s
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// Failures to retry, defaults to all of them
+	// +optional
+	RetryOn []RetryReason `json:"retryOn,omitempty"`
+}
+
+// WorkflowRun summarises the status of a previous run of a Workflow
+// +k8s:openapi-gen=true
+type WorkflowRun struct {
+	// Index of the run
+	Run int32 `json:"run"`
+	// The value of the st4sd.ibm.com/rerun annotation which started the run
+	// +optional
+	Rerun string `json:"rerun,omitempty"`
+	// Name of the last primary pod of the run
+	Pod   string        `json:"pod"`
+	Phase WorkflowPhase `json:"phase,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	Experimentstate string `json:"experimentstate,omitempty"`
+	// +optional
+	Exitstatus string `json:"exitstatus,omitempty"`
+	// +optional
+	Errordescription string `json:"errordescription,omitempty"`
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// +optional
+	Outputfiles map[string]map[string]string `json:"outputfiles,omitempty"`
+}
+
+// ChildResourcesStatus counts the pods and jobs that the orchestrator created for a Workflow. These have
+// the same "workflow" label as the primary pod of the Workflow
+// +k8s:openapi-gen=true
+type ChildResourcesStatus struct {
+	Pods int32 `json:"pods"`
+	Jobs int32 `json:"jobs"`
+	// Number of leftover pods that the operator deleted after the Workflow finished
+	// +optional
+	DeletedPods int32 `json:"deletedPods,omitempty"`
+	// Number of leftover jobs that the operator deleted after the Workflow finished
+	// +optional
+	DeletedJobs int32 `json:"deletedJobs,omitempty"`
+}
+
+// WorkflowAttempt describes a primary pod of a Workflow that failed and that the operator replaced
+// +k8s:openapi-gen=true
+type WorkflowAttempt struct {
+	// Name of the pod
+	Pod string `json:"pod"`
+	// The failure of the pod
+	Reason RetryReason `json:"reason"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time that the operator observed the failure
+	FinishedAt metav1.Time `json:"finishedAt"`
+}
+
+// TTLCleanup is what the operator deletes when the ttlSecondsAfterFinished of a Workflow expires
+type TTLCleanup string
+
+const (
+	TTLCleanupWorkflow TTLCleanup = "Workflow"
+	TTLCleanupPods     TTLCleanup = "Pods"
+)
+
+// WorkflowPhase is the phase of a Workflow in its lifecycle, the operator manages it
+type WorkflowPhase string
+
+const (
+	// WorkflowWaiting is a Workflow whose pod the operator will create after its dependencies complete
+	WorkflowWaiting WorkflowPhase = "Waiting"
+	// WorkflowRunning is a Workflow whose pod exists and has not terminated
+	WorkflowRunning WorkflowPhase = "Running"
+	// WorkflowSucceeded is a Workflow whose orchestrator reported success
+	WorkflowSucceeded WorkflowPhase = "Succeeded"
+	// WorkflowFailed is a Workflow that terminated without success, or that cannot start
+	WorkflowFailed WorkflowPhase = "Failed"
+	// WorkflowCancelled is a Workflow that stopped because spec.cancel is true
+	WorkflowCancelled WorkflowPhase = "Cancelled"
+	// WorkflowSuspended is a Workflow without a pod because spec.suspend is true
+	WorkflowSuspended WorkflowPhase = "Suspended"
+	// WorkflowQueued is a Workflow waiting for the admission queue of its namespace to start its pod
+	WorkflowQueued WorkflowPhase = "Queued"
+	// WorkflowHeld is a Workflow without a pod because spec.hold is true
+	WorkflowHeld WorkflowPhase = "Held"
+)
+
+// Reasons for the phase of a Workflow
+const (
+	ReasonWaitingForDependencies     = "WaitingForDependencies"
+	ReasonDependencyFailed           = "DependencyFailed"
+	ReasonPodFailed                  = "PodFailed"
+	ReasonOrchestratorFailed         = "OrchestratorFailed"
+	ReasonCancelled                  = "Cancelled"
+	ReasonSuspended                  = "Suspended"
+	ReasonInvalidRestart             = "InvalidRestart"
+	ReasonDeadlineExceeded           = "DeadlineExceeded"
+	ReasonRetryBackoff               = "RetryBackoff"
+	ReasonHeld                       = "Held"
+	ReasonQueued                     = "Queued"
+	ReasonWaitingForKueue            = "WaitingForKueue"
+	ReasonInvalidPodTemplate         = "InvalidPodTemplate"
+	ReasonPreflightFailed            = "PreflightFailed"
+	ReasonInvalidResources           = "InvalidResources"
+	ReasonUnknownResourceProfile     = "UnknownResourceProfile"
+	ReasonInvalidOrchestratorOptions = "InvalidOrchestratorOptions"
+	ReasonInvalidInputFiles          = "InvalidInputFiles"
+	ReasonInvalidPackage             = "InvalidPackage"
+)
+
 // WorkflowStatus defines the observed state of Workflow
 // +k8s:openapi-gen=true
 type WorkflowStatus struct {
+	// Phase of the Workflow, the operator manages it
+	// +optional
+	Phase WorkflowPhase `json:"phase,omitempty"`
+	// Time that the Workflow entered the Succeeded, Failed, or Cancelled phase
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Machine readable explanation of the phase
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Human readable explanation of the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
 	Cost             string `json:"cost,omitempty"`
 	Currentstage     string `json:"currentstage,omitempty"`
 	Exitstatus       string `json:"exitstatus,omitempty"`
@@ -158,8 +630,124 @@ type WorkflowStatus struct {
 
 	Outputfiles map[string]map[string]string `json:"outputfiles,omitempty"`
 	Meta        string                       `json:"meta,omitempty"`
+
+	// Name of the instance directory in the working volume, the operator sets it when it creates the primary pod
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+
+	// Index of the current run of the Workflow, it increases every time the st4sd.ibm.com/rerun
+	// annotation changes
+	// +optional
+	Run int32 `json:"run,omitempty"`
+	// The value of the st4sd.ibm.com/rerun annotation which started the current run
+	// +optional
+	ObservedRerun string `json:"observedRerun,omitempty"`
+	// Summaries of the previous runs of the Workflow, most recent last
+	// +optional
+	History []WorkflowRun `json:"history,omitempty"`
+
+	// Primary pods that failed and that the operator replaced because of spec.retryPolicy, the name of
+	// the current primary pod ends with -attempt-$numberOfAttempts
+	// +optional
+	Attempts []WorkflowAttempt `json:"attempts,omitempty"`
+
+	// The pods and jobs that the orchestrator created for the Workflow
+	// +optional
+	ChildResources *ChildResourcesStatus `json:"childResources,omitempty"`
+
+	// Position of the Workflow in the admission queue of its namespace (1 is the next to start),
+	// set while status.phase is Queued
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// The instance directory and stage that the next pod of the Workflow restarts from
+	// +optional
+	Restart *WorkflowRestart `json:"restart,omitempty"`
+
+	// Progress of uploading outputs to spec.s3BucketOutput
+	// +optional
+	S3Upload *S3UploadStatus `json:"s3Upload,omitempty"`
+
+	// Report of fetching spec.s3BucketInput.objects
+	// +optional
+	S3Fetch *S3FetchStatus `json:"s3Fetch,omitempty"`
+
+	// Names of the Workflows that this Workflow depends on which have met their condition
+	// +optional
+	CompletedDependencies []string `json:"completedDependencies,omitempty"`
+}
+
+// RestartFrom references a Workflow in the same namespace to restart from one of its stages
+// +k8s:openapi-gen=true
+type RestartFrom struct {
+	// Name of the Workflow to restart
+	// +kubebuilder:validation:MinLength=1
+	Workflow string `json:"workflow"`
+	// Index of the stage to restart from (--restart)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Stage int32 `json:"stage,omitempty"`
 }
 
+// WorkflowRestart describes an instance directory and the stage to restart it from
+// +k8s:openapi-gen=true
+type WorkflowRestart struct {
+	// Name of the instance directory in the working volume
+	InstanceName string `json:"instanceName"`
+	// Index of the stage to restart from (--restart)
+	Stage int32 `json:"stage"`
+}
+
+// S3FetchedObject describes an object that the operator fetched from a S3 bucket
+// +k8s:openapi-gen=true
+type S3FetchedObject struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	// The checksum that the object was verified with, one of etag, sha256 (empty if unverified)
+	// +optional
+	Verified string `json:"verified,omitempty"`
+}
+
+// S3FetchStatus reports the objects that the operator fetched from a S3 bucket
+// +k8s:openapi-gen=true
+type S3FetchStatus struct {
+	// +optional
+	Objects      []S3FetchedObject `json:"objects,omitempty"`
+	TotalObjects int               `json:"totalObjects"`
+	TotalBytes   int64             `json:"totalBytes"`
+	// Set when objects contains just the first fetched objects because the report was too large
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// S3UploadState is the state of uploading outputs to a S3 bucket
+type S3UploadState string
+
+const (
+	S3UploadRunning   S3UploadState = "Running"
+	S3UploadSucceeded S3UploadState = "Succeeded"
+	S3UploadFailed    S3UploadState = "Failed"
+	S3UploadSkipped   S3UploadState = "Skipped"
+)
+
+// S3UploadStatus reports the progress of uploading outputs to a S3 bucket
+// +k8s:openapi-gen=true
+type S3UploadStatus struct {
+	State S3UploadState `json:"state,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Values of WorkflowStatus.Experimentstate and WorkflowStatus.Exitstatus that the monitoring
+// side-container reports
+const (
+	ExperimentStateFinished = "finished"
+	ExperimentStateFailed   = "failed"
+	ExitStatusSuccess       = "Success"
+)
+
 // DefaultWorkflowOptions holds default options to automatically generate parts of the
 // workflow definition
 // +k8s:openapi-gen=false
@@ -167,11 +755,36 @@ type DefaultWorkflowOptions struct {
 	GitSyncImage            string   `json:"gitSyncImage,omitempty"`
 	WorkflowMonitoringImage string   `json:"workflowMonitoringImage,omitempty"`
 	S3FetchFilesImage       string   `json:"s3FetchFilesImage,omitempty"`
+	S3UploadFilesImage      string   `json:"s3UploadFilesImage,omitempty"`
+	TTLSecondsAfterFinished *int32   `json:"ttlSecondsAfterFinished,omitempty"`
+	FinalizerTimeoutSeconds *int32   `json:"finalizerTimeoutSeconds,omitempty"`
 	FlowImage               string   `json:"flowImage,omitempty"`
 	ImagePullSecrets        []string `json:"imagePullSecrets,omitempty"`
 	WorkingVolume           string   `json:"workingVolume,omitempty"`
 	GitSecret               string   `json:"gitSecret,omitempty"`
 	GitSecretOAuth          string   `json:"gitSecretOAuth,omitempty"`
+
+	// Admission queue limits for the namespace of a Workflow, nil means unlimited
+	MaxConcurrentWorkflows *int32             `json:"maxConcurrentWorkflows,omitempty"`
+	MaxConcurrentCPU       *resource.Quantity `json:"maxConcurrentCPU,omitempty"`
+	MaxConcurrentMemory    *resource.Quantity `json:"maxConcurrentMemory,omitempty"`
+	// Label with the owner of a Workflow for fair-share ordering of the admission queue
+	FairShareLabel string `json:"fairShareLabel,omitempty"`
+
+	// Resource profiles of the containers in the primary pod
+	ResourceProfiles map[string]Resourcespec `json:"resourceProfiles,omitempty"`
+
+	// Defaults for scheduling the primary pod
+	NodeSelector              map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations               []v1.Toleration               `json:"tolerations,omitempty"`
+	Affinity                  *v1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                        `json:"priorityClassName,omitempty"`
+	RuntimeClassName          *string                       `json:"runtimeClassName,omitempty"`
+
+	// Defaults of the containers and security context of the primary pod
+	Env             []v1.EnvVar            `json:"env,omitempty"`
+	SecurityContext *v1.PodSecurityContext `json:"securityContext,omitempty"`
 }
 
 // ConsumableComputingConfig describes the contents of the `config.json` data entry of
@@ -184,15 +797,32 @@ type ConsumableComputingConfig struct {
 	WorkingVolume           string   `json:"workingVolume,omitempty"`
 	InputDataDir            string   `json:"inputdatadir,omitempty"`
 	S3FetchFilesImage       string   `json:"s3-fetch-files-image,omitempty"`
+	S3UploadFilesImage      string   `json:"s3-upload-files-image,omitempty"`
+	TTLSecondsAfterFinished *int32   `json:"ttl-seconds-after-finished,omitempty"`
+	FinalizerTimeoutSeconds *int32   `json:"finalizer-timeout-seconds,omitempty"`
+	MaxConcurrentWorkflows  *int32   `json:"max-concurrent-workflows,omitempty"`
+	MaxConcurrentCPU        string   `json:"max-concurrent-cpu,omitempty"`
+	MaxConcurrentMemory     string   `json:"max-concurrent-memory,omitempty"`
+	FairShareLabel          string   `json:"fair-share-label,omitempty"`
 	GitSyncImage            string   `json:"git-sync-image,omitempty"`
 	WorkflowMonitoringImage string   `json:"workflow-monitoring-image,omitempty"`
 	ImagePullSecrets        []string `json:"imagePullSecrets,omitempty"`
+
+	NodeSelector              map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations               []v1.Toleration               `json:"tolerations,omitempty"`
+	Affinity                  *v1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                        `json:"priorityClassName,omitempty"`
+	RuntimeClassName          *string                       `json:"runtimeClassName,omitempty"`
+
+	Profiles map[string]Resourcespec `json:"profiles,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=workflows,shortName=wf
 // +kubebuilder:printcolumn:name="age",type="string",JSONPath=".metadata.creationTimestamp",description="Age of the workflow instance"
 // +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.experimentstate",description="Status of the workflow instance"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="Phase of the workflow in its lifecycle"
 // Workflow is the Schema for the workflows API
 type Workflow struct {
 	metav1.TypeMeta   `json:",inline"`
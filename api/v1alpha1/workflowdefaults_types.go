@@ -0,0 +1,120 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkflowDefaultsSpec holds the default values of Workflows. Empty fields do not override the
+// default values of earlier sources
+// +k8s:openapi-gen=true
+type WorkflowDefaultsSpec struct {
+	// Image of the workflow orchestrator, the default of spec.image
+	// +optional
+	Image string `json:"image,omitempty"`
+	// +optional
+	GitSyncImage string `json:"gitSyncImage,omitempty"`
+	// +optional
+	WorkflowMonitoringImage string `json:"workflowMonitoringImage,omitempty"`
+	// +optional
+	S3FetchFilesImage string `json:"s3FetchFilesImage,omitempty"`
+	// +optional
+	S3UploadFilesImage string `json:"s3UploadFilesImage,omitempty"`
+
+	// Secret with the SSH key for git@ package URLs
+	// +optional
+	GitSecret string `json:"gitSecret,omitempty"`
+	// Secret with the OAuth token for https:// package URLs
+	// +optional
+	GitSecretOAuth string `json:"gitSecretOAuth,omitempty"`
+	// +optional
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// Name of the PersistentVolumeClaim for spec.workingVolume
+	// +optional
+	WorkingVolume string `json:"workingVolume,omitempty"`
+
+	// Resource profiles that spec.resources.profile selects, the "default" profile applies to all Workflows.
+	// A profile replaces the profile with the same name of earlier sources
+	// +optional
+	ResourceProfiles map[string]Resourcespec `json:"resourceProfiles,omitempty"`
+
+	// Constraints for scheduling the primary pod
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// +optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// +optional
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// Environment variables for the containers of the primary pod, spec.env overrides variables with
+	// the same name. A variable replaces the variable with the same name of earlier sources
+	// +optional
+	Env []v1.EnvVar `json:"env,omitempty"`
+
+	// Security context of the primary pod, replaces the one from the USER_ID, GROUP_ID, and FSGROUP
+	// environment variables of the operator
+	// +optional
+	SecurityContext *v1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// The default of spec.ttlSecondsAfterFinished
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=workflowdefaults,scope=Namespaced
+// WorkflowDefaults holds the default values of the Workflows in its namespace
+type WorkflowDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkflowDefaultsSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// WorkflowDefaultsList contains a list of WorkflowDefaults
+type WorkflowDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkflowDefaults `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterworkflowdefaults,scope=Cluster
+// ClusterWorkflowDefaults holds the default values of the Workflows in all namespaces
+type ClusterWorkflowDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkflowDefaultsSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterWorkflowDefaultsList contains a list of ClusterWorkflowDefaults
+type ClusterWorkflowDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterWorkflowDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkflowDefaults{}, &WorkflowDefaultsList{},
+		&ClusterWorkflowDefaults{}, &ClusterWorkflowDefaultsList{})
+}
@@ -12,17 +12,144 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChildResourcesStatus) DeepCopyInto(out *ChildResourcesStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChildResourcesStatus.
+func (in *ChildResourcesStatus) DeepCopy() *ChildResourcesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChildResourcesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkflowDefaults) DeepCopyInto(out *ClusterWorkflowDefaults) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkflowDefaults.
+func (in *ClusterWorkflowDefaults) DeepCopy() *ClusterWorkflowDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkflowDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkflowDefaults) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkflowDefaultsList) DeepCopyInto(out *ClusterWorkflowDefaultsList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterWorkflowDefaults, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkflowDefaultsList.
+func (in *ClusterWorkflowDefaultsList) DeepCopy() *ClusterWorkflowDefaultsList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkflowDefaultsList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkflowDefaultsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsumableComputingConfig) DeepCopyInto(out *ConsumableComputingConfig) {
 	*out = *in
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FinalizerTimeoutSeconds != nil {
+		in, out := &in.FinalizerTimeoutSeconds, &out.FinalizerTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrentWorkflows != nil {
+		in, out := &in.MaxConcurrentWorkflows, &out.MaxConcurrentWorkflows
+		*out = new(int32)
+		**out = **in
+	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make(map[string]Resourcespec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsumableComputingConfig.
@@ -54,11 +181,86 @@ func (in *DatashimS3BucketInfo) DeepCopy() *DatashimS3BucketInfo {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DefaultWorkflowOptions) DeepCopyInto(out *DefaultWorkflowOptions) {
 	*out = *in
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FinalizerTimeoutSeconds != nil {
+		in, out := &in.FinalizerTimeoutSeconds, &out.FinalizerTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxConcurrentWorkflows != nil {
+		in, out := &in.MaxConcurrentWorkflows, &out.MaxConcurrentWorkflows
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrentCPU != nil {
+		in, out := &in.MaxConcurrentCPU, &out.MaxConcurrentCPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxConcurrentMemory != nil {
+		in, out := &in.MaxConcurrentMemory, &out.MaxConcurrentMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ResourceProfiles != nil {
+		in, out := &in.ResourceProfiles, &out.ResourceProfiles
+		*out = make(map[string]Resourcespec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultWorkflowOptions.
@@ -81,148 +283,578 @@ func (in *Gitrepo) DeepCopyInto(out *Gitrepo) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gitrepo.
-func (in *Gitrepo) DeepCopy() *Gitrepo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gitrepo.
+func (in *Gitrepo) DeepCopy() *Gitrepo {
+	if in == nil {
+		return nil
+	}
+	out := new(Gitrepo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputFile) DeepCopyInto(out *InputFile) {
+	*out = *in
+	if in.FromWorkflow != nil {
+		in, out := &in.FromWorkflow, &out.FromWorkflow
+		*out = new(WorkflowOutputReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputFile.
+func (in *InputFile) DeepCopy() *InputFile {
+	if in == nil {
+		return nil
+	}
+	out := new(InputFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrchestratorOptions) DeepCopyInto(out *OrchestratorOptions) {
+	*out = *in
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RestartFromStage != nil {
+		in, out := &in.RestartFromStage, &out.RestartFromStage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrchestratorOptions.
+func (in *OrchestratorOptions) DeepCopy() *OrchestratorOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(OrchestratorOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateMetadata) DeepCopyInto(out *PodTemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateMetadata.
+func (in *PodTemplateMetadata) DeepCopy() *PodTemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateOverlay) DeepCopyInto(out *PodTemplateOverlay) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateOverlay.
+func (in *PodTemplateOverlay) DeepCopy() *PodTemplateOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Resourcedefinition) DeepCopyInto(out *Resourcedefinition) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resourcedefinition.
+func (in *Resourcedefinition) DeepCopy() *Resourcedefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(Resourcedefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Resourcespec) DeepCopyInto(out *Resourcespec) {
+	*out = *in
+	if in.ElaunchPrimary != nil {
+		in, out := &in.ElaunchPrimary, &out.ElaunchPrimary
+		*out = new(Resourcedefinition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitor != nil {
+		in, out := &in.Monitor, &out.Monitor
+		*out = new(Resourcedefinition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitFetch != nil {
+		in, out := &in.GitFetch, &out.GitFetch
+		*out = new(Resourcedefinition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.S3Fetch != nil {
+		in, out := &in.S3Fetch, &out.S3Fetch
+		*out = new(Resourcedefinition)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resourcespec.
+func (in *Resourcespec) DeepCopy() *Resourcespec {
+	if in == nil {
+		return nil
+	}
+	out := new(Resourcespec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartFrom) DeepCopyInto(out *RestartFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartFrom.
+func (in *RestartFrom) DeepCopy() *RestartFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]RetryReason, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3BucketInfo) DeepCopyInto(out *S3BucketInfo) {
+	*out = *in
+	in.AccessKeyID.DeepCopyInto(&out.AccessKeyID)
+	in.SecretAccessKey.DeepCopyInto(&out.SecretAccessKey)
+	in.Endpoint.DeepCopyInto(&out.Endpoint)
+	in.Bucket.DeepCopyInto(&out.Bucket)
+	in.Region.DeepCopyInto(&out.Region)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketInfo.
+func (in *S3BucketInfo) DeepCopy() *S3BucketInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(S3BucketInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3BucketInputInfo) DeepCopyInto(out *S3BucketInputInfo) {
+	*out = *in
+	in.DatashimS3BucketInfo.DeepCopyInto(&out.DatashimS3BucketInfo)
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]S3ObjectSelector, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxTotalSize != nil {
+		in, out := &in.MaxTotalSize, &out.MaxTotalSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketInputInfo.
+func (in *S3BucketInputInfo) DeepCopy() *S3BucketInputInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(S3BucketInputInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3BucketOutputInfo) DeepCopyInto(out *S3BucketOutputInfo) {
+	*out = *in
+	in.DatashimS3BucketInfo.DeepCopyInto(&out.DatashimS3BucketInfo)
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketOutputInfo.
+func (in *S3BucketOutputInfo) DeepCopy() *S3BucketOutputInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(S3BucketOutputInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3FetchStatus) DeepCopyInto(out *S3FetchStatus) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]S3FetchedObject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3FetchStatus.
+func (in *S3FetchStatus) DeepCopy() *S3FetchStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(S3FetchStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3FetchedObject) DeepCopyInto(out *S3FetchedObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3FetchedObject.
+func (in *S3FetchedObject) DeepCopy() *S3FetchedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(S3FetchedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3InputVariable) DeepCopyInto(out *S3InputVariable) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(v1.EnvVarSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3InputVariable.
+func (in *S3InputVariable) DeepCopy() *S3InputVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(S3InputVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3ObjectSelector) DeepCopyInto(out *S3ObjectSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3ObjectSelector.
+func (in *S3ObjectSelector) DeepCopy() *S3ObjectSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(S3ObjectSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3UploadStatus) DeepCopyInto(out *S3UploadStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3UploadStatus.
+func (in *S3UploadStatus) DeepCopy() *S3UploadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(S3UploadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourcePathToTargetFileName) DeepCopyInto(out *SourcePathToTargetFileName) {
+	*out = *in
+	if in.TargetName != nil {
+		in, out := &in.TargetName, &out.TargetName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourcePathToTargetFileName.
+func (in *SourcePathToTargetFileName) DeepCopy() *SourcePathToTargetFileName {
 	if in == nil {
 		return nil
 	}
-	out := new(Gitrepo)
+	out := new(SourcePathToTargetFileName)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Resourcedefinition) DeepCopyInto(out *Resourcedefinition) {
+func (in *Workflow) DeepCopyInto(out *Workflow) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resourcedefinition.
-func (in *Resourcedefinition) DeepCopy() *Resourcedefinition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workflow.
+func (in *Workflow) DeepCopy() *Workflow {
 	if in == nil {
 		return nil
 	}
-	out := new(Resourcedefinition)
+	out := new(Workflow)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Workflow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Resourcespec) DeepCopyInto(out *Resourcespec) {
+func (in *WorkflowAttempt) DeepCopyInto(out *WorkflowAttempt) {
 	*out = *in
-	if in.ElaunchPrimary != nil {
-		in, out := &in.ElaunchPrimary, &out.ElaunchPrimary
-		*out = new(Resourcedefinition)
-		**out = **in
-	}
-	if in.Monitor != nil {
-		in, out := &in.Monitor, &out.Monitor
-		*out = new(Resourcedefinition)
-		**out = **in
-	}
-	if in.GitFetch != nil {
-		in, out := &in.GitFetch, &out.GitFetch
-		*out = new(Resourcedefinition)
-		**out = **in
-	}
+	in.FinishedAt.DeepCopyInto(&out.FinishedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resourcespec.
-func (in *Resourcespec) DeepCopy() *Resourcespec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowAttempt.
+func (in *WorkflowAttempt) DeepCopy() *WorkflowAttempt {
 	if in == nil {
 		return nil
 	}
-	out := new(Resourcespec)
+	out := new(WorkflowAttempt)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3BucketInfo) DeepCopyInto(out *S3BucketInfo) {
+func (in *WorkflowDefaults) DeepCopyInto(out *WorkflowDefaults) {
 	*out = *in
-	in.AccessKeyID.DeepCopyInto(&out.AccessKeyID)
-	in.SecretAccessKey.DeepCopyInto(&out.SecretAccessKey)
-	in.Endpoint.DeepCopyInto(&out.Endpoint)
-	in.Bucket.DeepCopyInto(&out.Bucket)
-	in.Region.DeepCopyInto(&out.Region)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketInfo.
-func (in *S3BucketInfo) DeepCopy() *S3BucketInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowDefaults.
+func (in *WorkflowDefaults) DeepCopy() *WorkflowDefaults {
 	if in == nil {
 		return nil
 	}
-	out := new(S3BucketInfo)
+	out := new(WorkflowDefaults)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkflowDefaults) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3InputVariable) DeepCopyInto(out *S3InputVariable) {
+func (in *WorkflowDefaultsList) DeepCopyInto(out *WorkflowDefaultsList) {
 	*out = *in
-	if in.ValueFrom != nil {
-		in, out := &in.ValueFrom, &out.ValueFrom
-		*out = new(v1.EnvVarSource)
-		(*in).DeepCopyInto(*out)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkflowDefaults, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3InputVariable.
-func (in *S3InputVariable) DeepCopy() *S3InputVariable {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowDefaultsList.
+func (in *WorkflowDefaultsList) DeepCopy() *WorkflowDefaultsList {
 	if in == nil {
 		return nil
 	}
-	out := new(S3InputVariable)
+	out := new(WorkflowDefaultsList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkflowDefaultsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SourcePathToTargetFileName) DeepCopyInto(out *SourcePathToTargetFileName) {
+func (in *WorkflowDefaultsSpec) DeepCopyInto(out *WorkflowDefaultsSpec) {
 	*out = *in
-	if in.TargetName != nil {
-		in, out := &in.TargetName, &out.TargetName
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceProfiles != nil {
+		in, out := &in.ResourceProfiles, &out.ResourceProfiles
+		*out = make(map[string]Resourcespec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
 		*out = new(string)
 		**out = **in
 	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourcePathToTargetFileName.
-func (in *SourcePathToTargetFileName) DeepCopy() *SourcePathToTargetFileName {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowDefaultsSpec.
+func (in *WorkflowDefaultsSpec) DeepCopy() *WorkflowDefaultsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SourcePathToTargetFileName)
+	out := new(WorkflowDefaultsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Workflow) DeepCopyInto(out *Workflow) {
+func (in *WorkflowDependency) DeepCopyInto(out *WorkflowDependency) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workflow.
-func (in *Workflow) DeepCopy() *Workflow {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowDependency.
+func (in *WorkflowDependency) DeepCopy() *WorkflowDependency {
 	if in == nil {
 		return nil
 	}
-	out := new(Workflow)
+	out := new(WorkflowDependency)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Workflow) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowList) DeepCopyInto(out *WorkflowList) {
 	*out = *in
@@ -255,6 +887,73 @@ func (in *WorkflowList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowOutputReference) DeepCopyInto(out *WorkflowOutputReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowOutputReference.
+func (in *WorkflowOutputReference) DeepCopy() *WorkflowOutputReference {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowOutputReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowRestart) DeepCopyInto(out *WorkflowRestart) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowRestart.
+func (in *WorkflowRestart) DeepCopy() *WorkflowRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowRun) DeepCopyInto(out *WorkflowRun) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Outputfiles != nil {
+		in, out := &in.Outputfiles, &out.Outputfiles
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowRun.
+func (in *WorkflowRun) DeepCopy() *WorkflowRun {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 	*out = *in
@@ -268,6 +967,11 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 		*out = new(Gitrepo)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RestartFrom != nil {
+		in, out := &in.RestartFrom, &out.RestartFrom
+		*out = new(RestartFrom)
+		**out = **in
+	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
 		*out = make([]v1.Volume, len(*in))
@@ -293,6 +997,13 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.InputFiles != nil {
+		in, out := &in.InputFiles, &out.InputFiles
+		*out = make([]InputFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Variables != nil {
 		in, out := &in.Variables, &out.Variables
 		*out = make([]string, len(*in))
@@ -303,6 +1014,11 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.OrchestratorOptions != nil {
+		in, out := &in.OrchestratorOptions, &out.OrchestratorOptions
+		*out = new(OrchestratorOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.AdditionalOptions != nil {
 		in, out := &in.AdditionalOptions, &out.AdditionalOptions
 		*out = make([]string, len(*in))
@@ -322,7 +1038,83 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 	}
 	if in.S3BucketInput != nil {
 		in, out := &in.S3BucketInput, &out.S3BucketInput
-		*out = new(DatashimS3BucketInfo)
+		*out = new(S3BucketInputInfo)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]WorkflowDependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.S3BucketOutput != nil {
+		in, out := &in.S3BucketOutput, &out.S3BucketOutput
+		*out = new(S3BucketOutputInfo)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PackageFetchTimeout != nil {
+		in, out := &in.PackageFetchTimeout, &out.PackageFetchTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplateOverlay)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -340,6 +1132,10 @@ func (in *WorkflowSpec) DeepCopy() *WorkflowSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
 	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 	if in.Stages != nil {
 		in, out := &in.Stages, &out.Stages
 		*out = make([]string, len(*in))
@@ -363,6 +1159,45 @@ func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]WorkflowRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]WorkflowAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ChildResources != nil {
+		in, out := &in.ChildResources, &out.ChildResources
+		*out = new(ChildResourcesStatus)
+		**out = **in
+	}
+	if in.Restart != nil {
+		in, out := &in.Restart, &out.Restart
+		*out = new(WorkflowRestart)
+		**out = **in
+	}
+	if in.S3Upload != nil {
+		in, out := &in.S3Upload, &out.S3Upload
+		*out = new(S3UploadStatus)
+		**out = **in
+	}
+	if in.S3Fetch != nil {
+		in, out := &in.S3Fetch, &out.S3Fetch
+		*out = new(S3FetchStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletedDependencies != nil {
+		in, out := &in.CompletedDependencies, &out.CompletedDependencies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStatus.
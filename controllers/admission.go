@@ -0,0 +1,322 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// admissionRequeueDelay is how often the operator checks whether a queued Workflow may start
+const admissionRequeueDelay = 15 * time.Second
+
+// podRequests returns the resources that the scheduler reserves for a pod i.e. the sum of the requests
+// of its containers or the largest request of its init containers, whichever is larger
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for name, quantity := range c.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		for name, quantity := range c.Resources.Requests {
+			if total, ok := requests[name]; !ok || quantity.Cmp(total) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	return requests
+}
+
+// workflowPriority returns spec.priority of a Workflow, or else the value of its spec.priorityClassName,
+// or else 0. The classes map caches the values of PriorityClasses.
+func (r *WorkflowReconciler) workflowPriority(ctx context.Context, wf *st4sdv1alpha1.Workflow,
+	classes map[string]int32) (int32, error) {
+	if wf.Spec.Priority != nil {
+		return *wf.Spec.Priority, nil
+	}
+	name := wf.Spec.PriorityClassName
+	if name == "" {
+		return 0, nil
+	}
+	if value, ok := classes[name]; ok {
+		return value, nil
+	}
+
+	priorityClass := &schedulingv1.PriorityClass{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name}, priorityClass)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	// VV: Kubernetes rejects the pod of a Workflow with a missing PriorityClass, the value does not matter
+	classes[name] = priorityClass.Value
+	return priorityClass.Value, nil
+}
+
+// workflowOwner returns the user that fair-share ordering charges a Workflow to, i.e. the value of
+// the label fairShareLabel or else the field manager of the request that created the Workflow.
+// A field manager identifies the client program (e.g. kubectl or the st4sd-runtime-service), not the
+// user, so Workflows that the same program creates for different users share it. Administrators should
+// set fair-share-label to identify users. Kubernetes records a create request as an Update entry in
+// managedFields, the entry of the creator is the one with the time of metadata.creationTimestamp (or
+// else the earliest time).
+func workflowOwner(wf *st4sdv1alpha1.Workflow, fairShareLabel string) string {
+	if owner, ok := wf.Labels[fairShareLabel]; ok && fairShareLabel != "" {
+		return owner
+	}
+
+	var creator *metav1.ManagedFieldsEntry
+	for i := range wf.ManagedFields {
+		entry := &wf.ManagedFields[i]
+		if entry.Time == nil {
+			if creator == nil {
+				creator = entry
+			}
+			continue
+		}
+		if entry.Time.Equal(&wf.CreationTimestamp) {
+			creator = entry
+			break
+		}
+		if creator == nil || creator.Time == nil || entry.Time.Before(creator.Time) {
+			creator = entry
+		}
+	}
+	if creator == nil {
+		return ""
+	}
+	return creator.Manager
+}
+
+// queuedWorkflow is a Workflow in the admission queue along with what orders it
+type queuedWorkflow struct {
+	workflow *st4sdv1alpha1.Workflow
+	priority int32
+	owner    string
+}
+
+// orderAdmissionQueue returns the queued Workflows in the order they should start. Workflows with
+// a higher priority go first. Among Workflows with the same priority, those whose owner has fewer
+// running and earlier queued Workflows go first so that one user cannot starve the others, and
+// then the oldest Workflows go first. The running map is the number of running Workflows per owner.
+func orderAdmissionQueue(queue []queuedWorkflow, running map[string]int) []*st4sdv1alpha1.Workflow {
+	remaining := append([]queuedWorkflow{}, queue...)
+	counts := map[string]int{}
+	for owner, count := range running {
+		counts[owner] = count
+	}
+
+	before := func(one, other queuedWorkflow) bool {
+		if one.priority != other.priority {
+			return one.priority > other.priority
+		}
+		if counts[one.owner] != counts[other.owner] {
+			return counts[one.owner] < counts[other.owner]
+		}
+		oneCreated, otherCreated := &one.workflow.CreationTimestamp, &other.workflow.CreationTimestamp
+		if !oneCreated.Equal(otherCreated) {
+			return oneCreated.Before(otherCreated)
+		}
+		return one.workflow.Name < other.workflow.Name
+	}
+
+	ordered := []*st4sdv1alpha1.Workflow{}
+	for len(remaining) > 0 {
+		next := 0
+		for i := range remaining {
+			if before(remaining[i], remaining[next]) {
+				next = i
+			}
+		}
+		ordered = append(ordered, remaining[next].workflow)
+		counts[remaining[next].owner]++
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return ordered
+}
+
+// inAdmissionQueue returns true for Workflows that are waiting to start their primary pod, including new
+// ones that the operator has not reconciled yet. New Workflows that cannot start, because they wait for
+// other Workflows or because they are invalid, are not in the queue so that they do not block the ones
+// after them.
+func (r *WorkflowReconciler) inAdmissionQueue(ctx context.Context, wf *st4sdv1alpha1.Workflow) (bool, error) {
+	if wf.Spec.QueueName != "" {
+		return false, nil
+	}
+	if wf.Status.Phase == st4sdv1alpha1.WorkflowQueued {
+		return true, nil
+	}
+	if wf.Status.Phase != "" || len(wf.Status.Updated) != 0 || wf.DeletionTimestamp != nil ||
+		wf.Spec.Hold || wf.Spec.Suspend || wf.Spec.Cancel {
+		return false, nil
+	}
+
+	if message, _, err := r.checkDependencies(ctx, wf); err != nil || message != "" {
+		return false, err
+	}
+
+	wf = wf.DeepCopy()
+	if wf.Spec.RestartFrom != nil && wf.Status.Restart == nil {
+		if message, err := r.applyRestartFrom(ctx, wf); err != nil || message != "" {
+			return false, err
+		}
+	}
+
+	if _, err := newPodForCR(r, wf); podErrorReason(err) != "" {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// exceedsLimit returns a message explaining why adding request to used exceeds limit, or "" if it does not
+func exceedsLimit(name corev1.ResourceName, used resource.Quantity, request resource.Quantity,
+	limit *resource.Quantity) string {
+	if limit == nil {
+		return ""
+	}
+	used.Add(request)
+	if used.Cmp(*limit) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("the running workflows and this one request %s %s (limit %s)",
+		used.String(), name, limit.String())
+}
+
+// admitWorkflow decides whether the operator may create the primary pod of a Workflow given the admission
+// queue limits of its namespace. Workflows that may not start yet move to the Queued phase. Workflows
+// start in the order of orderAdmissionQueue and a Workflow may not skip ahead of the ones before it.
+func (r *WorkflowReconciler) admitWorkflow(ctx context.Context, instance *st4sdv1alpha1.Workflow,
+	pod *corev1.Pod) (bool, error) {
+	// VV: Kueue manages the quota of Workflows with spec.queueName
+	if instance.Spec.QueueName != "" {
+		return true, nil
+	}
+
+	options := getDefaultValues(r, instance.Namespace, configMapName())
+	if options.MaxConcurrentWorkflows == nil && options.MaxConcurrentCPU == nil && options.MaxConcurrentMemory == nil {
+		return true, nil
+	}
+
+	found := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, found)
+	if err == nil {
+		return true, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	workflows := &st4sdv1alpha1.WorkflowList{}
+	if err := r.Client.List(ctx, workflows, client.InNamespace(instance.Namespace)); err != nil {
+		return false, err
+	}
+
+	classes := map[string]int32{}
+	queue := []queuedWorkflow{}
+	enqueue := func(wf *st4sdv1alpha1.Workflow) error {
+		priority, err := r.workflowPriority(ctx, wf, classes)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, queuedWorkflow{workflow: wf, priority: priority,
+			owner: workflowOwner(wf, options.FairShareLabel)})
+		return nil
+	}
+	if err := enqueue(instance); err != nil {
+		return false, err
+	}
+
+	running := 0
+	runningPerOwner := map[string]int{}
+	var usedCPU, usedMemory resource.Quantity
+
+	for i := range workflows.Items {
+		wf := &workflows.Items[i]
+		if wf.Name == instance.Name {
+			continue
+		}
+
+		queued, err := r.inAdmissionQueue(ctx, wf)
+		if err != nil {
+			return false, err
+		}
+
+		if queued {
+			if err := enqueue(wf); err != nil {
+				return false, err
+			}
+		} else if wf.Status.Phase == st4sdv1alpha1.WorkflowRunning {
+			running++
+			runningPerOwner[workflowOwner(wf, options.FairShareLabel)]++
+			wfPod := &corev1.Pod{}
+			err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(wf), Namespace: wf.Namespace}, wfPod)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return false, err
+			}
+			requests := podRequests(wfPod)
+			usedCPU.Add(requests[corev1.ResourceCPU])
+			usedMemory.Add(requests[corev1.ResourceMemory])
+		}
+	}
+
+	position := 0
+	for i, wf := range orderAdmissionQueue(queue, runningPerOwner) {
+		if wf.Name == instance.Name {
+			position = i
+			break
+		}
+	}
+
+	reason := ""
+	if position > 0 {
+		reason = fmt.Sprintf("%d workflows are ahead in the queue", position)
+	} else if options.MaxConcurrentWorkflows != nil && int32(running) >= *options.MaxConcurrentWorkflows {
+		reason = fmt.Sprintf("%d workflows are running (limit %d)", running, *options.MaxConcurrentWorkflows)
+	} else if running > 0 {
+		// VV: A Workflow that exceeds the resource limits on its own starts when nothing else is running
+		// so that it does not block the queue forever
+		requests := podRequests(pod)
+		reason = exceedsLimit(corev1.ResourceCPU, usedCPU, requests[corev1.ResourceCPU], options.MaxConcurrentCPU)
+		if reason == "" {
+			reason = exceedsLimit(corev1.ResourceMemory, usedMemory, requests[corev1.ResourceMemory],
+				options.MaxConcurrentMemory)
+		}
+	}
+
+	if reason == "" {
+		instance.Status.QueuePosition = 0
+		return true, nil
+	}
+
+	instance.Status.QueuePosition = int32(position + 1)
+	message := fmt.Sprintf("waiting for admission at position %d of the queue of namespace %s, %s",
+		position+1, instance.Namespace, reason)
+	return false, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowQueued, st4sdv1alpha1.ReasonQueued, message)
+}
@@ -0,0 +1,217 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestAdmissionQueue tests that Workflows wait in the Queued phase while the namespace runs
+// the maximum number of Workflows and that they start in the order they were created
+func TestAdmissionQueue(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_WORKFLOWS", "1")
+
+	now := time.Now()
+	names := []string{"queue-a", "queue-b", "queue-c"}
+	workflows := []*st4sdv1alpha1.Workflow{}
+	for i, name := range names {
+		wf := newTestWorkflow(name)
+		wf.CreationTimestamp = metav1.NewTime(now.Add(time.Duration(i) * time.Second))
+		workflows = append(workflows, wf)
+	}
+
+	r := newTestReconciler(workflows[0], workflows[1], workflows[2])
+
+	// VV: Reconcile in the reverse order to check that the queue does not depend on it
+	for i := len(names) - 1; i >= 0; i-- {
+		reconcileTestWorkflow(t, r, names[i])
+	}
+
+	if getTestPod(r, "queue-c") != nil {
+		t.Fatal("Did not expect queue-c to skip ahead of queue-a and queue-b")
+	}
+
+	reconcileTestWorkflow(t, r, "queue-b")
+	reconcileTestWorkflow(t, r, "queue-c")
+	for i, name := range names[1:] {
+		wf := getTestWorkflow(t, r, name)
+		if wf.Status.Phase != st4sdv1alpha1.WorkflowQueued || wf.Status.QueuePosition != int32(i+1) {
+			t.Error("Unexpected queue status", "name", name, "status", wf.Status)
+		}
+		if getTestPod(r, name) != nil {
+			t.Error("Did not expect a pod for queued workflow", name)
+		}
+	}
+	if getTestPod(r, "queue-a") == nil {
+		t.Fatal("Expected a pod for queue-a")
+	}
+
+	finishTestWorkflow(t, r, "queue-a", 0)
+
+	reconcileTestWorkflow(t, r, "queue-c")
+	reconcileTestWorkflow(t, r, "queue-b")
+	if getTestPod(r, "queue-b") == nil {
+		t.Fatal("Expected a pod for queue-b", "status", getTestWorkflow(t, r, "queue-b").Status)
+	}
+	if wf := getTestWorkflow(t, r, "queue-b"); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning || wf.Status.QueuePosition != 0 {
+		t.Error("Expected queue-b to run", "status", wf.Status)
+	}
+
+	reconcileTestWorkflow(t, r, "queue-c")
+	if wf := getTestWorkflow(t, r, "queue-c"); wf.Status.Phase != st4sdv1alpha1.WorkflowQueued || wf.Status.QueuePosition != 1 {
+		t.Error("Expected queue-c to be next in the queue", "status", wf.Status)
+	}
+}
+
+// TestAdmissionQueueInvalid tests that an invalid Workflow at the head of the queue fails instead of
+// blocking the Workflows after it
+func TestAdmissionQueueInvalid(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_WORKFLOWS", "1")
+
+	now := time.Now()
+	broken := newTestWorkflow("queue-broken")
+	broken.CreationTimestamp = metav1.NewTime(now)
+	broken.Spec.Instance = "previous.instance"
+	valid := newTestWorkflow("queue-valid")
+	valid.CreationTimestamp = metav1.NewTime(now.Add(time.Second))
+
+	r := newTestReconciler(broken, valid)
+
+	// VV: The operator has not reconciled queue-broken yet, its phase is still empty
+	reconcileTestWorkflow(t, r, valid.Name)
+	if getTestPod(r, valid.Name) == nil {
+		t.Fatal("Expected a pod for queue-valid", "status", getTestWorkflow(t, r, valid.Name).Status)
+	}
+
+	reconcileTestWorkflow(t, r, broken.Name)
+	if wf := getTestWorkflow(t, r, broken.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonInvalidPackage {
+		t.Error("Expected queue-broken to fail with InvalidPackage", "status", wf.Status)
+	}
+	if getTestPod(r, broken.Name) != nil {
+		t.Error("Did not expect a pod for queue-broken")
+	}
+}
+
+// TestAdmissionQueuePriority tests that Workflows with a higher priority start first and that
+// Workflows with the same priority alternate between users
+func TestAdmissionQueuePriority(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_WORKFLOWS", "1")
+	t.Setenv("FAIR_SHARE_LABEL", "user")
+
+	high := &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}, Value: 10}
+	now := time.Now()
+	newWorkflow := func(name string, user string, created int) *st4sdv1alpha1.Workflow {
+		wf := newTestWorkflow(name)
+		wf.Labels = map[string]string{"user": user}
+		wf.CreationTimestamp = metav1.NewTime(now.Add(time.Duration(created) * time.Second))
+		return wf
+	}
+
+	running := newWorkflow("priority-running", "alice", 0)
+	r := newTestReconciler(running, high)
+	reconcileTestWorkflow(t, r, running.Name)
+
+	urgent := newWorkflow("priority-urgent", "bob", 4)
+	urgent.Spec.PriorityClassName = "high"
+	queued := []*st4sdv1alpha1.Workflow{
+		newWorkflow("priority-alice-1", "alice", 1),
+		newWorkflow("priority-alice-2", "alice", 2),
+		newWorkflow("priority-bob", "bob", 3),
+		urgent,
+	}
+	for _, wf := range queued {
+		if err := r.Create(context.TODO(), wf); err != nil {
+			t.Fatal("Unable to create Workflow", err)
+		}
+	}
+
+	for _, wf := range queued {
+		reconcileTestWorkflow(t, r, wf.Name)
+	}
+
+	expected := map[string]int32{"priority-urgent": 1, "priority-alice-1": 2, "priority-bob": 3, "priority-alice-2": 4}
+	for name, position := range expected {
+		wf := getTestWorkflow(t, r, name)
+		if wf.Status.Phase != st4sdv1alpha1.WorkflowQueued || wf.Status.QueuePosition != position {
+			t.Error("Unexpected queue status", "name", name, "expected", position, "status", wf.Status)
+		}
+	}
+
+	finishTestWorkflow(t, r, running.Name, 0)
+	reconcileTestWorkflow(t, r, urgent.Name)
+	pod := getTestPod(r, urgent.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod for", urgent.Name)
+	}
+	if pod.Spec.PriorityClassName != "high" {
+		t.Error("Expected the priority class of the Workflow", "priorityClassName", pod.Spec.PriorityClassName)
+	}
+}
+
+// TestPodRequests tests that the requests of init containers do not add up with those of the containers
+func TestPodRequests(t *testing.T) {
+	requests := func(cpu string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}
+	}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Resources: requests("3")}, {Resources: requests("1")}},
+		Containers:     []corev1.Container{{Resources: requests("1")}, {Resources: requests("500m")}},
+	}}
+
+	cpu := podRequests(pod)[corev1.ResourceCPU]
+	if cpu.Cmp(resource.MustParse("3")) != 0 {
+		t.Error("Expected 3 cpus", "cpu", cpu.String())
+	}
+
+	pod.Spec.InitContainers = pod.Spec.InitContainers[1:]
+	cpu = podRequests(pod)[corev1.ResourceCPU]
+	if cpu.Cmp(resource.MustParse("1500m")) != 0 {
+		t.Error("Expected 1500m cpus", "cpu", cpu.String())
+	}
+}
+
+// TestWorkflowOwner tests that the owner of a Workflow is its fair-share label or else the field manager
+// of the request that created it
+func TestWorkflowOwner(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	updated := metav1.NewTime(created.Add(time.Minute))
+
+	wf := newTestWorkflow("owner")
+	wf.Labels = map[string]string{"st4sd.ibm.com/user": "alice"}
+	wf.CreationTimestamp = created
+	wf.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "unknown", Operation: metav1.ManagedFieldsOperationUpdate},
+		{Manager: "st4sd-operator", Operation: metav1.ManagedFieldsOperationUpdate, Time: &updated},
+		{Manager: "kubectl-create", Operation: metav1.ManagedFieldsOperationUpdate, Time: &created},
+	}
+
+	if owner := workflowOwner(wf, "st4sd.ibm.com/user"); owner != "alice" {
+		t.Error("Expected the owner in the fair-share label", "owner", owner)
+	}
+	if owner := workflowOwner(wf, ""); owner != "kubectl-create" {
+		t.Error("Expected the field manager which created the Workflow", "owner", owner)
+	}
+
+	wf.ManagedFields[2].Time = &updated
+	wf.ManagedFields[1].Time = nil
+	if owner := workflowOwner(wf, ""); owner != "kubectl-create" {
+		t.Error("Expected the field manager with the earliest time", "owner", owner)
+	}
+}
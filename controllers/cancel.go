@@ -0,0 +1,84 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// stopPod makes the kubelet send SIGTERM to the containers of a pod and kill them after
+// terminationGracePeriodSeconds. Unlike deleting the pod, this keeps the pod and its logs around.
+// Pods with scheduling gates never started, stopPod deletes them.
+func (r *WorkflowReconciler) stopPod(ctx context.Context, reqLogger logr.Logger, pod *corev1.Pod) error {
+	if len(pod.Spec.SchedulingGates) > 0 {
+		reqLogger.Info("Deleting unscheduled Pod", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+		return client.IgnoreNotFound(r.Client.Delete(ctx, pod))
+	}
+
+	// VV: activeDeadlineSeconds can only decrease and must be positive
+	if pod.Spec.ActiveDeadlineSeconds != nil && *pod.Spec.ActiveDeadlineSeconds <= 1 {
+		return nil
+	}
+
+	deadline := int64(1)
+	pod.Spec.ActiveDeadlineSeconds = &deadline
+
+	reqLogger.Info("Stopping Pod", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+	return r.Client.Update(ctx, pod)
+}
+
+// cancelWorkflow stops the primary pod of a Workflow and moves the Workflow to the Cancelled phase.
+// Workflows without a pod never start.
+func (r *WorkflowReconciler) cancelWorkflow(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	message := "the workflow was cancelled before it started"
+
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	} else if err == nil {
+		message = "the workflow was cancelled"
+
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			if err := r.stopPod(ctx, reqLogger, pod); err != nil {
+				return err
+			}
+
+			gracePeriod := int64(600)
+			if pod.Spec.TerminationGracePeriodSeconds != nil {
+				gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+			}
+			message = fmt.Sprintf("the workflow was cancelled, the orchestrator has %d seconds to shut down",
+				gracePeriod)
+		}
+	}
+
+	if err := r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowCancelled, st4sdv1alpha1.ReasonCancelled,
+		message); err != nil {
+		return err
+	}
+
+	event := newWorkflowEvent(instance, "Normal", st4sdv1alpha1.ReasonCancelled, message)
+	if err := r.Client.Create(ctx, event); err != nil {
+		reqLogger.Error(err, "Error in creating event")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestCancel tests that cancelling a running Workflow stops its pod without deleting it and that
+// cancelling a Workflow which has not started prevents it from starting
+func TestCancel(t *testing.T) {
+	running := newTestWorkflow("running")
+	waiting := newTestWorkflow("waiting")
+	waiting.Spec.DependsOn = []st4sdv1alpha1.WorkflowDependency{{Name: "running"}}
+
+	r := newTestReconciler(running, waiting)
+	ctx := context.TODO()
+
+	reconcileTestWorkflow(t, r, running.Name)
+	reconcileTestWorkflow(t, r, waiting.Name)
+
+	for _, name := range []string{running.Name, waiting.Name} {
+		wf := getTestWorkflow(t, r, name)
+		wf.Spec.Cancel = true
+		if err := r.Update(ctx, wf); err != nil {
+			t.Fatal("Unable to update Workflow", err)
+		}
+		reconcileTestWorkflow(t, r, name)
+
+		if updated := getTestWorkflow(t, r, name); updated.Status.Phase != st4sdv1alpha1.WorkflowCancelled {
+			t.Error("Expected", name, "to be cancelled", "status", updated.Status)
+		}
+	}
+
+	pod := getTestPod(r, running.Name)
+	if pod == nil {
+		t.Fatal("Expected the pod of the cancelled Workflow to exist")
+	}
+	if pod.Spec.ActiveDeadlineSeconds == nil || *pod.Spec.ActiveDeadlineSeconds != 1 {
+		t.Error("Expected the pod to be stopped via activeDeadlineSeconds", "spec", pod.Spec)
+	}
+
+	reconcileTestWorkflow(t, r, waiting.Name)
+	if getTestPod(r, waiting.Name) != nil {
+		t.Error("Did not expect a pod for the cancelled Workflow", waiting.Name)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// isChildResource returns true if an object is a pod or job that the orchestrator of a Workflow created.
+// These have the "workflow" label of the Workflow, they do not belong to the Workflow, and if they
+// have a "rest-uid" label then it matches the uid of the Workflow
+func isChildResource(cr *st4sdv1alpha1.Workflow, obj metav1.Object) bool {
+	if metav1.IsControlledBy(obj, cr) || obj.GetDeletionTimestamp() != nil {
+		return false
+	}
+	if uid, ok := obj.GetLabels()["rest-uid"]; ok && uid != fmt.Sprint(cr.UID) {
+		return false
+	}
+	return true
+}
+
+// listChildResources returns the pods and jobs that the orchestrator of a Workflow created and which
+// are not being deleted
+func (r *WorkflowReconciler) listChildResources(ctx context.Context, cr *st4sdv1alpha1.Workflow) (
+	[]corev1.Pod, []batchv1.Job, error) {
+	selector := []client.ListOption{
+		client.InNamespace(cr.Namespace),
+		client.MatchingLabels{"workflow": workflowLabel(cr)},
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList, selector...); err != nil {
+		return nil, nil, err
+	}
+	pods := []corev1.Pod{}
+	for _, v := range podList.Items {
+		if isChildResource(cr, &v) {
+			pods = append(pods, v)
+		}
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := r.Client.List(ctx, jobList, selector...); err != nil {
+		return nil, nil, err
+	}
+	jobs := []batchv1.Job{}
+	for _, v := range jobList.Items {
+		if isChildResource(cr, &v) {
+			jobs = append(jobs, v)
+		}
+	}
+
+	return pods, jobs, nil
+}
+
+// deleteChildResources deletes pods and jobs, and returns how many it deleted
+func (r *WorkflowReconciler) deleteChildResources(ctx context.Context, reqLogger logr.Logger,
+	pods []corev1.Pod, jobs []batchv1.Job) (int32, int32, error) {
+	deletedPods, deletedJobs := int32(0), int32(0)
+
+	for i := range jobs {
+		err := r.Client.Delete(ctx, &jobs[i], client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return deletedPods, deletedJobs, err
+		}
+		reqLogger.Info("Deleted Job", "Job.Namespace", jobs[i].Namespace, "Job.Name", jobs[i].Name)
+		deletedJobs++
+	}
+
+	for i := range pods {
+		if err := r.Client.Delete(ctx, &pods[i]); client.IgnoreNotFound(err) != nil {
+			return deletedPods, deletedJobs, err
+		}
+		reqLogger.Info("Deleted Pod", "Pod.Namespace", pods[i].Namespace, "Pod.Name", pods[i].Name)
+		deletedPods++
+	}
+
+	return deletedPods, deletedJobs, nil
+}
+
+// reconcileChildResources reports the number of pods and jobs that the orchestrator of a Workflow created
+// in status.childResources, and deletes them after the Workflow finishes
+func (r *WorkflowReconciler) reconcileChildResources(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	pods, jobs, err := r.listChildResources(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	status := st4sdv1alpha1.ChildResourcesStatus{}
+	if instance.Status.ChildResources != nil {
+		status = *instance.Status.ChildResources
+	} else if len(pods) == 0 && len(jobs) == 0 {
+		return nil
+	}
+
+	if phaseIsFinal(instance.Status.Phase) && len(pods)+len(jobs) > 0 {
+		deletedPods, deletedJobs, err := r.deleteChildResources(ctx, reqLogger, pods, jobs)
+		status.DeletedPods += deletedPods
+		status.DeletedJobs += deletedJobs
+		if err != nil {
+			reqLogger.Error(err, "Unable to delete the leftover pods and jobs of the Workflow")
+		} else {
+			pods, jobs = nil, nil
+		}
+	}
+
+	status.Pods = int32(len(pods))
+	status.Jobs = int32(len(jobs))
+
+	if instance.Status.ChildResources != nil && *instance.Status.ChildResources == status {
+		return nil
+	}
+
+	instance.Status.ChildResources = &status
+	return r.Client.Update(ctx, instance)
+}
@@ -0,0 +1,66 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestChildResources tests that the operator counts the pods and jobs of the orchestrator and deletes
+// the leftovers after the Workflow finishes
+func TestChildResources(t *testing.T) {
+	wf := newTestWorkflow("children")
+	wf.UID = "children-uid"
+	labels := map[string]string{"workflow": "children"}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "children-component", Namespace: "default", Labels: labels}}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "children-job", Namespace: "default", Labels: labels}}
+	// VV: Same workflow label but it belongs to an older Workflow object with the same name
+	stale := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "children-stale", Namespace: "default",
+		Labels: map[string]string{"workflow": "children", "rest-uid": "other-uid"}}}
+
+	r := newTestReconciler(wf, pod, job, stale)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	expected := st4sdv1alpha1.ChildResourcesStatus{Pods: 1, Jobs: 1}
+	if wf.Status.ChildResources == nil || *wf.Status.ChildResources != expected {
+		t.Fatal("Unexpected child resources", "actual", wf.Status.ChildResources, "expected", expected)
+	}
+
+	finishTestWorkflow(t, r, wf.Name, time.Minute)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	expected = st4sdv1alpha1.ChildResourcesStatus{DeletedPods: 1, DeletedJobs: 1}
+	if wf.Status.ChildResources == nil || *wf.Status.ChildResources != expected {
+		t.Error("Unexpected child resources", "actual", wf.Status.ChildResources, "expected", expected)
+	}
+	if getTestPod(r, pod.Name) != nil {
+		t.Error("Expected the leftover pod to be deleted")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, job); err == nil {
+		t.Error("Expected the leftover job to be deleted")
+	}
+	if getTestPod(r, stale.Name) == nil {
+		t.Error("Did not expect the pod of another Workflow to be deleted")
+	}
+}
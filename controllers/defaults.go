@@ -0,0 +1,131 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// applyWorkflowDefaults overrides options with the fields that defaults sets. Resource profiles and
+// environment variables replace those with the same name.
+func applyWorkflowDefaults(options *st4sdv1alpha1.DefaultWorkflowOptions, defaults *st4sdv1alpha1.WorkflowDefaultsSpec) {
+	defaults = defaults.DeepCopy()
+
+	if len(defaults.Image) > 0 {
+		options.FlowImage = defaults.Image
+	}
+	if len(defaults.GitSyncImage) > 0 {
+		options.GitSyncImage = defaults.GitSyncImage
+	}
+	if len(defaults.WorkflowMonitoringImage) > 0 {
+		options.WorkflowMonitoringImage = defaults.WorkflowMonitoringImage
+	}
+	if len(defaults.S3FetchFilesImage) > 0 {
+		options.S3FetchFilesImage = defaults.S3FetchFilesImage
+	}
+	if len(defaults.S3UploadFilesImage) > 0 {
+		options.S3UploadFilesImage = defaults.S3UploadFilesImage
+	}
+
+	if len(defaults.GitSecret) > 0 {
+		options.GitSecret = defaults.GitSecret
+	}
+	if len(defaults.GitSecretOAuth) > 0 {
+		options.GitSecretOAuth = defaults.GitSecretOAuth
+	}
+	if len(defaults.ImagePullSecrets) > 0 {
+		options.ImagePullSecrets = defaults.ImagePullSecrets
+	}
+	if len(defaults.WorkingVolume) > 0 {
+		options.WorkingVolume = defaults.WorkingVolume
+	}
+
+	for name, profile := range defaults.ResourceProfiles {
+		if options.ResourceProfiles == nil {
+			options.ResourceProfiles = map[string]st4sdv1alpha1.Resourcespec{}
+		}
+		options.ResourceProfiles[name] = profile
+	}
+
+	if len(defaults.NodeSelector) > 0 {
+		options.NodeSelector = defaults.NodeSelector
+	}
+	if len(defaults.Tolerations) > 0 {
+		options.Tolerations = defaults.Tolerations
+	}
+	if defaults.Affinity != nil {
+		options.Affinity = defaults.Affinity
+	}
+	if len(defaults.TopologySpreadConstraints) > 0 {
+		options.TopologySpreadConstraints = defaults.TopologySpreadConstraints
+	}
+	if len(defaults.PriorityClassName) > 0 {
+		options.PriorityClassName = defaults.PriorityClassName
+	}
+	if defaults.RuntimeClassName != nil {
+		options.RuntimeClassName = defaults.RuntimeClassName
+	}
+
+	for _, env := range defaults.Env {
+		replaced := false
+		for i := range options.Env {
+			if options.Env[i].Name == env.Name {
+				options.Env[i] = env
+				replaced = true
+			}
+		}
+		if !replaced {
+			options.Env = append(options.Env, env)
+		}
+	}
+
+	if defaults.SecurityContext != nil {
+		options.SecurityContext = defaults.SecurityContext
+	}
+	if defaults.TTLSecondsAfterFinished != nil {
+		options.TTLSecondsAfterFinished = defaults.TTLSecondsAfterFinished
+	}
+}
+
+// applyClusterWorkflowDefaults overrides options with the ClusterWorkflowDefaults in the order of their names
+func applyClusterWorkflowDefaults(r *WorkflowReconciler, options *st4sdv1alpha1.DefaultWorkflowOptions) {
+	list := &st4sdv1alpha1.ClusterWorkflowDefaultsList{}
+	if err := r.Client.List(context.TODO(), list); err != nil {
+		log.Log.WithName("getDefaultValues").Info("Unable to list ClusterWorkflowDefaults", "err", err)
+		return
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	for i := range list.Items {
+		applyWorkflowDefaults(options, &list.Items[i].Spec)
+	}
+}
+
+// applyNamespaceWorkflowDefaults overrides options with the WorkflowDefaults of a namespace in the order
+// of their names
+func applyNamespaceWorkflowDefaults(r *WorkflowReconciler, namespace string,
+	options *st4sdv1alpha1.DefaultWorkflowOptions) {
+	list := &st4sdv1alpha1.WorkflowDefaultsList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(namespace)); err != nil {
+		log.Log.WithName("getDefaultValues").Info("Unable to list WorkflowDefaults", "err", err)
+		return
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	for i := range list.Items {
+		applyWorkflowDefaults(options, &list.Items[i].Spec)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestWorkflowDefaults tests that the WorkflowDefaults of a namespace override the ConfigMap, which overrides
+// the ClusterWorkflowDefaults, and that the spec of the Workflow overrides all of them
+func TestWorkflowDefaults(t *testing.T) {
+	t.Setenv("GIT_SYNC_IMAGE", "env/git-sync")
+	t.Setenv("FLOW_IMAGE", "env/flow")
+
+	fsGroup := int64(1234)
+	cluster := &st4sdv1alpha1.ClusterWorkflowDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: st4sdv1alpha1.WorkflowDefaultsSpec{
+			Image:         "cluster/flow",
+			GitSyncImage:  "cluster/git-sync",
+			NodeSelector:  map[string]string{"pool": "control-plane"},
+			WorkingVolume: "cluster-pvc",
+			Env:           []corev1.EnvVar{{Name: "SITE", Value: "cluster"}, {Name: "PROXY", Value: "cluster"}},
+		},
+	}
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName(), Namespace: "default"},
+		Data:       map[string]string{"config.json": `{"image": "configmap/flow", "workingVolume": "configmap-pvc"}`},
+	}
+	namespaced := &st4sdv1alpha1.WorkflowDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: st4sdv1alpha1.WorkflowDefaultsSpec{
+			WorkingVolume:   "team-pvc",
+			Env:             []corev1.EnvVar{{Name: "SITE", Value: "team"}},
+			SecurityContext: &corev1.PodSecurityContext{FSGroup: &fsGroup},
+		},
+	}
+	other := &st4sdv1alpha1.WorkflowDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		Spec:       st4sdv1alpha1.WorkflowDefaultsSpec{Image: "other/flow"},
+	}
+
+	r := newTestReconciler(cluster, config, namespaced, other)
+	options := getDefaultValues(r, "default", configMapName())
+
+	if options.GitSyncImage != "cluster/git-sync" || options.FlowImage != "configmap/flow" {
+		t.Error("Unexpected images", "gitSyncImage", options.GitSyncImage, "flowImage", options.FlowImage)
+	}
+	if options.WorkingVolume != "team-pvc" || options.NodeSelector["pool"] != "control-plane" {
+		t.Error("Unexpected defaults", "options", options)
+	}
+
+	wf := newTestWorkflow("defaults")
+	wf.Spec.Env = []corev1.EnvVar{{Name: "PROXY", Value: "workflow"}}
+	pod, err := newPodForCR(r, wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	env := map[string]string{}
+	for _, v := range findContainer(pod.Spec.Containers, "elaunch-primary").Env {
+		env[v.Name] = v.Value
+	}
+	if env["SITE"] != "team" || env["PROXY"] != "workflow" {
+		t.Error("Unexpected environment variables", "env", env)
+	}
+	if pod.Spec.SecurityContext == nil || pod.Spec.SecurityContext.FSGroup == nil ||
+		*pod.Spec.SecurityContext.FSGroup != fsGroup {
+		t.Error("Expected the security context of the WorkflowDefaults", "securityContext", pod.Spec.SecurityContext)
+	}
+	if pod.Spec.NodeSelector["pool"] != "control-plane" {
+		t.Error("Expected the nodeSelector of the ClusterWorkflowDefaults", "nodeSelector", pod.Spec.NodeSelector)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// workflowDependencies returns the Workflows that a Workflow depends on. Workflows that it consumes outputs
+// of must succeed, so do the entries of spec.dependsOn without a condition. The Workflow that it restarts
+// must complete.
+func workflowDependencies(cr *st4sdv1alpha1.Workflow) []st4sdv1alpha1.WorkflowDependency {
+	deps := []st4sdv1alpha1.WorkflowDependency{}
+	names := []string{}
+
+	if cr.Spec.RestartFrom != nil {
+		deps = append(deps, st4sdv1alpha1.WorkflowDependency{
+			Name: cr.Spec.RestartFrom.Workflow, Condition: st4sdv1alpha1.DependencyCompleted})
+	}
+
+	for _, v := range cr.Spec.InputFiles {
+		if v.FromWorkflow != nil && !contains(names, v.FromWorkflow.Name) {
+			names = append(names, v.FromWorkflow.Name)
+			deps = append(deps, st4sdv1alpha1.WorkflowDependency{
+				Name: v.FromWorkflow.Name, Condition: st4sdv1alpha1.DependencySucceeded})
+		}
+	}
+
+	for _, v := range cr.Spec.DependsOn {
+		dep := v
+		if dep.Condition == "" {
+			dep.Condition = st4sdv1alpha1.DependencySucceeded
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps
+}
+
+// upstreamWorkflowNames returns the names of the Workflows that a Workflow depends on
+func upstreamWorkflowNames(cr *st4sdv1alpha1.Workflow) []string {
+	names := []string{}
+
+	for _, v := range workflowDependencies(cr) {
+		if !contains(names, v.Name) {
+			names = append(names, v.Name)
+		}
+	}
+
+	return names
+}
+
+// upstreamWorkflowsField is the field index of Workflows by the names of the Workflows they depend on
+const upstreamWorkflowsField = "spec.upstreamWorkflows"
+
+// indexUpstreamWorkflows returns the values of upstreamWorkflowsField for a Workflow
+func indexUpstreamWorkflows(obj client.Object) []string {
+	cr, ok := obj.(*st4sdv1alpha1.Workflow)
+	if !ok {
+		return nil
+	}
+	return upstreamWorkflowNames(cr)
+}
+
+// downstreamWorkflows maps a Workflow to reconcile requests for the Workflows in the same namespace
+// which depend on it
+func (r *WorkflowReconciler) downstreamWorkflows(ctx context.Context, obj client.Object) []reconcile.Request {
+	workflows := &st4sdv1alpha1.WorkflowList{}
+	if err := r.Client.List(ctx, workflows, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{upstreamWorkflowsField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "Unable to list Workflows", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, v := range workflows.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: v.Name, Namespace: v.Namespace}})
+	}
+
+	return requests
+}
+
+// checkDependencies returns a non-empty message explaining why the Workflow cannot start yet because
+// of the Workflows it depends on. When failed is true, the Workflow will never be able to start.
+// It records the dependencies which meet their condition in status.completedDependencies so that
+// deleting one of them later fails the Workflow instead of making it wait for the dependency to be created.
+func (r *WorkflowReconciler) checkDependencies(ctx context.Context, cr *st4sdv1alpha1.Workflow) (
+	message string, failed bool, err error) {
+	for _, dep := range workflowDependencies(cr) {
+		upstream := &st4sdv1alpha1.Workflow{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: dep.Name, Namespace: cr.Namespace}, upstream)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return "", false, err
+			} else if contains(cr.Status.CompletedDependencies, dep.Name) {
+				return fmt.Sprintf("Workflow %s was deleted before this Workflow started", dep.Name), true, nil
+			}
+			return fmt.Sprintf("waiting for Workflow %s to be created", dep.Name), false, nil
+		}
+
+		switch dep.Condition {
+		case st4sdv1alpha1.DependencyCompleted:
+			if !upstream.Status.Completed() {
+				return fmt.Sprintf("waiting for Workflow %s to complete", dep.Name), false, nil
+			}
+		default:
+			if upstream.Status.Failed() {
+				return fmt.Sprintf("Workflow %s did not succeed", dep.Name), true, nil
+			} else if !upstream.Status.Succeeded() {
+				return fmt.Sprintf("waiting for Workflow %s to succeed", dep.Name), false, nil
+			}
+		}
+
+		if !contains(cr.Status.CompletedDependencies, dep.Name) {
+			cr.Status.CompletedDependencies = append(cr.Status.CompletedDependencies, dep.Name)
+		}
+	}
+
+	return "", false, nil
+}
+
+// workflowOutputPath returns the path in the working volume of a key-output of a Workflow that succeeded
+func workflowOutputPath(upstream *st4sdv1alpha1.Workflow, output string) (string, error) {
+	if !upstream.Status.Succeeded() {
+		return "", fmt.Errorf("workflow %s has not succeeded", upstream.Name)
+	}
+
+	keyOutput, ok := upstream.Status.Outputfiles[output]
+	if !ok || keyOutput["filepath"] == "" {
+		return "", fmt.Errorf("workflow %s does not have the key-output %s", upstream.Name, output)
+	}
+
+	filePath := keyOutput["filepath"]
+	if path.IsAbs(filePath) {
+		return filePath, nil
+	}
+
+	instanceDir := instanceDirectory(upstream)
+	if instanceDir == "" {
+		return "", fmt.Errorf("the instance directory of workflow %s is unknown", upstream.Name)
+	}
+
+	return path.Join(instanceDir, filePath), nil
+}
+
+// workflowInputError is an entry of spec.inputFiles whose fromWorkflow the operator cannot resolve
+// e.g. because the Workflow does not exist
+type workflowInputError struct {
+	index int
+	err   error
+}
+
+func (e *workflowInputError) Error() string {
+	return fmt.Sprintf("spec.inputFiles[%d].fromWorkflow: %v", e.index, e.err)
+}
+
+// resolveWorkflowOutputs returns the paths of the spec.inputFiles entries with a fromWorkflow field,
+// indexed by their position in spec.inputFiles
+func resolveWorkflowOutputs(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (map[int]string, error) {
+	paths := map[int]string{}
+
+	for i, v := range cr.Spec.InputFiles {
+		if v.FromWorkflow == nil {
+			continue
+		}
+
+		upstream := &st4sdv1alpha1.Workflow{}
+		err := r.Client.Get(context.TODO(),
+			types.NamespacedName{Name: v.FromWorkflow.Name, Namespace: cr.Namespace}, upstream)
+		if errors.IsNotFound(err) {
+			return nil, &workflowInputError{index: i, err: fmt.Errorf("workflow %s does not exist", v.FromWorkflow.Name)}
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !sameWorkingVolume(upstream, cr) {
+			return nil, &workflowInputError{index: i,
+				err: fmt.Errorf("workflow %s uses a different working volume", upstream.Name)}
+		}
+
+		paths[i], err = workflowOutputPath(upstream, v.FromWorkflow.Output)
+		if err != nil {
+			return nil, &workflowInputError{index: i, err: err}
+		}
+	}
+
+	return paths, nil
+}
+
+// sameWorkingVolume returns true if the Workflows use the same PersistentVolumeClaim as their working volume
+func sameWorkingVolume(one *st4sdv1alpha1.Workflow, other *st4sdv1alpha1.Workflow) bool {
+	if one.Spec.WorkingVolume.PersistentVolumeClaim == nil || other.Spec.WorkingVolume.PersistentVolumeClaim == nil {
+		return one.Spec.WorkingVolume.Name == other.Spec.WorkingVolume.Name
+	}
+	return one.Spec.WorkingVolume.PersistentVolumeClaim.ClaimName ==
+		other.Spec.WorkingVolume.PersistentVolumeClaim.ClaimName
+}
@@ -0,0 +1,216 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestInputFromWorkflow tests that the outputs of an upstream Workflow become inputs of a downstream one
+// only after the upstream Workflow succeeds
+func TestInputFromWorkflow(t *testing.T) {
+	upstream := newTestWorkflow("upstream")
+	upstream.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	upstream.Status.Exitstatus = st4sdv1alpha1.ExitStatusSuccess
+	upstream.Status.InstanceName = "upstream-123.instance"
+	upstream.Status.Outputfiles = map[string]map[string]string{
+		"Energies": {"filepath": "output/energies.csv"},
+	}
+
+	downstream := newTestWorkflow("downstream")
+	downstream.Spec.InputFiles = []st4sdv1alpha1.InputFile{
+		{FromWorkflow: &st4sdv1alpha1.WorkflowOutputReference{Name: "upstream", Output: "Energies"}, Rename: "in.csv"},
+	}
+
+	r := newTestReconciler(upstream, downstream)
+
+	if message, failed, err := r.checkDependencies(context.TODO(), downstream); err != nil || message != "" || failed {
+		t.Fatal("Expected downstream to be ready", "message", message, "err", err)
+	}
+
+	pod, err := newPodForCR(r, downstream)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if !contains(primary.Command, "/tmp/workdir/upstream-123.instance/output/energies.csv:in.csv") {
+		t.Error("Missing input from upstream workflow", "command", primary.Command)
+	}
+
+	requests := r.downstreamWorkflows(context.TODO(), upstream)
+	if len(requests) != 1 || requests[0].Name != "downstream" {
+		t.Error("Expected a reconcile request for downstream", requests)
+	}
+}
+
+// TestInputFromWorkflowWaiting tests that a downstream Workflow waits for its upstream Workflows
+func TestInputFromWorkflowWaiting(t *testing.T) {
+	upstream := newTestWorkflow("upstream")
+	upstream.Status.Experimentstate = "running"
+
+	downstream := newTestWorkflow("downstream")
+	downstream.Spec.InputFiles = []st4sdv1alpha1.InputFile{
+		{FromWorkflow: &st4sdv1alpha1.WorkflowOutputReference{Name: "upstream", Output: "Energies"}},
+		{FromWorkflow: &st4sdv1alpha1.WorkflowOutputReference{Name: "missing", Output: "Energies"}},
+	}
+
+	r := newTestReconciler(upstream, downstream)
+	if message, failed, err := r.checkDependencies(context.TODO(), downstream); err != nil || message == "" || failed {
+		t.Error("Expected downstream to wait", "err", err)
+	}
+
+	if _, err := newPodForCR(r, downstream); err == nil {
+		t.Error("Expected an error because upstream has not succeeded")
+	}
+}
+
+// TestDependsOn tests that Reconcile holds Workflows in the Waiting phase until their dependencies
+// complete and fails them if a dependency that must succeed fails
+func TestDependsOn(t *testing.T) {
+	upstream := newTestWorkflow("upstream")
+	upstream.Status.Experimentstate = "running"
+
+	succeeded := newTestWorkflow("needs-success")
+	succeeded.Spec.DependsOn = []st4sdv1alpha1.WorkflowDependency{{Name: "upstream"}}
+
+	completed := newTestWorkflow("needs-completion")
+	completed.Spec.DependsOn = []st4sdv1alpha1.WorkflowDependency{
+		{Name: "upstream", Condition: st4sdv1alpha1.DependencyCompleted}}
+
+	r := newTestReconciler(upstream, succeeded, completed)
+	ctx := context.TODO()
+
+	for _, wf := range []*st4sdv1alpha1.Workflow{succeeded, completed} {
+		reconcileTestWorkflow(t, r, wf.Name)
+		updated := getTestWorkflow(t, r, wf.Name)
+		if updated.Status.Phase != st4sdv1alpha1.WorkflowWaiting ||
+			updated.Status.Reason != st4sdv1alpha1.ReasonWaitingForDependencies {
+			t.Error("Expected", wf.Name, "to wait", "status", updated.Status)
+		}
+		if getTestPod(r, wf.Name) != nil {
+			t.Error("Did not expect a pod for", wf.Name)
+		}
+	}
+
+	upstream = getTestWorkflow(t, r, "upstream")
+	upstream.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	upstream.Status.Exitstatus = "Failed"
+	if err := r.Update(ctx, upstream); err != nil {
+		t.Fatal("Unable to update upstream", err)
+	}
+
+	reconcileTestWorkflow(t, r, succeeded.Name)
+	if updated := getTestWorkflow(t, r, succeeded.Name); updated.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		updated.Status.Reason != st4sdv1alpha1.ReasonDependencyFailed {
+		t.Error("Expected", succeeded.Name, "to fail", "status", updated.Status)
+	}
+
+	reconcileTestWorkflow(t, r, completed.Name)
+	if updated := getTestWorkflow(t, r, completed.Name); updated.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected", completed.Name, "to run", "status", updated.Status)
+	}
+	if getTestPod(r, completed.Name) == nil {
+		t.Error("Expected a pod for", completed.Name)
+	}
+}
+
+// TestDependencyDeleted tests that deleting a dependency does not affect a Workflow which has started and
+// fails a Workflow which has not started yet
+func TestDependencyDeleted(t *testing.T) {
+	upstream := newTestWorkflow("upstream")
+	upstream.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	upstream.Status.Exitstatus = st4sdv1alpha1.ExitStatusSuccess
+
+	started := newTestWorkflow("started")
+	started.Spec.DependsOn = []st4sdv1alpha1.WorkflowDependency{{Name: "upstream"}}
+
+	held := newTestWorkflow("held")
+	held.Spec.Hold = true
+	held.Spec.DependsOn = []st4sdv1alpha1.WorkflowDependency{{Name: "upstream"}}
+
+	r := newTestReconciler(upstream, started, held, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-sync-config", Namespace: "default"}})
+	ctx := context.TODO()
+
+	reconcileTestWorkflow(t, r, started.Name)
+	reconcileTestWorkflow(t, r, held.Name)
+	if getTestPod(r, started.Name) == nil {
+		t.Fatal("Expected a pod for", started.Name)
+	}
+	if wf := getTestWorkflow(t, r, held.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowHeld ||
+		!contains(wf.Status.CompletedDependencies, "upstream") {
+		t.Fatal("Expected", held.Name, "to be held", "status", wf.Status)
+	}
+
+	if err := r.Delete(ctx, getTestWorkflow(t, r, "upstream")); err != nil {
+		t.Fatal("Unable to delete upstream", err)
+	}
+
+	reconcileTestWorkflow(t, r, started.Name)
+	if wf := getTestWorkflow(t, r, started.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected", started.Name, "to keep running", "status", wf.Status)
+	}
+
+	wf := getTestWorkflow(t, r, held.Name)
+	wf.Spec.Hold = false
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+	reconcileTestWorkflow(t, r, held.Name)
+	if wf = getTestWorkflow(t, r, held.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonDependencyFailed {
+		t.Error("Expected", held.Name, "to fail", "status", wf.Status)
+	}
+}
+
+// TestInputFromDeletedWorkflow tests that a deleted fromWorkflow source is a terminal error before the pod
+// of the downstream Workflow exists and that it does not affect a downstream Workflow which is running
+func TestInputFromDeletedWorkflow(t *testing.T) {
+	upstream := newTestWorkflow("upstream")
+	upstream.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	upstream.Status.Exitstatus = st4sdv1alpha1.ExitStatusSuccess
+	upstream.Status.InstanceName = "upstream-123.instance"
+	upstream.Status.Outputfiles = map[string]map[string]string{
+		"Energies": {"filepath": "output/energies.csv"},
+	}
+
+	downstream := newTestWorkflow("downstream")
+	downstream.Spec.InputFiles = []st4sdv1alpha1.InputFile{
+		{FromWorkflow: &st4sdv1alpha1.WorkflowOutputReference{Name: "upstream", Output: "Energies"}},
+	}
+
+	r := newTestReconciler(upstream, downstream)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, downstream.Name)
+	if getTestPod(r, downstream.Name) == nil {
+		t.Fatal("Expected a pod for downstream", "status", getTestWorkflow(t, r, downstream.Name).Status)
+	}
+
+	if err := r.Delete(ctx, getTestWorkflow(t, r, "upstream")); err != nil {
+		t.Fatal("Unable to delete upstream", err)
+	}
+
+	reconcileTestWorkflow(t, r, downstream.Name)
+	if wf := getTestWorkflow(t, r, downstream.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected downstream to keep running", "status", wf.Status)
+	}
+
+	_, err := newPodForCR(r, downstream)
+	if reason := podErrorReason(err); reason != st4sdv1alpha1.ReasonDependencyFailed {
+		t.Error("Expected a deleted source to fail the Workflow", "reason", reason, "err", err)
+	}
+}
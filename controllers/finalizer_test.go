@@ -0,0 +1,65 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestFinalizer tests that deleting a Workflow stops its orchestrator, waits for the primary pod
+// to terminate, and then deletes the pods that the orchestrator created
+func TestFinalizer(t *testing.T) {
+	wf := newTestWorkflow("finalizer")
+	child := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "finalizer-stage0-component", Namespace: "default", Labels: map[string]string{"workflow": "finalizer"}}}
+	unrelated := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "other-stage0-component", Namespace: "default", Labels: map[string]string{"workflow": "other"}}}
+
+	r := newTestReconciler(wf, child, unrelated)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if len(wf.Finalizers) != 1 {
+		t.Fatal("Expected the Workflow to have a finalizer", "finalizers", wf.Finalizers)
+	}
+	if err := r.Delete(ctx, wf); err != nil {
+		t.Fatal("Unable to delete Workflow", err)
+	}
+
+	if result := reconcileTestWorkflow(t, r, wf.Name); result.RequeueAfter == 0 {
+		t.Error("Expected to wait for the primary pod to terminate")
+	}
+	pod := getTestPod(r, wf.Name)
+	if pod.Spec.ActiveDeadlineSeconds == nil || *pod.Spec.ActiveDeadlineSeconds != 1 {
+		t.Error("Expected the primary pod to be stopped", "spec", pod.Spec)
+	}
+	if getTestPod(r, child.Name) == nil {
+		t.Error("Did not expect the child pod to be deleted before the primary pod terminates")
+	}
+
+	succeedTestPod(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if getTestPod(r, child.Name) != nil {
+		t.Error("Expected the child pod to be deleted")
+	}
+	if getTestPod(r, unrelated.Name) == nil {
+		t.Error("Did not expect the pod of another Workflow to be deleted")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: wf.Name, Namespace: wf.Namespace}, wf); err == nil {
+		t.Error("Expected the Workflow to be deleted")
+	}
+}
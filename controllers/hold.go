@@ -0,0 +1,140 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// preflightChecks returns the problems that would prevent the primary pod of a Workflow from starting
+func (r *WorkflowReconciler) preflightChecks(ctx context.Context, cr *st4sdv1alpha1.Workflow,
+	pod *corev1.Pod) ([]string, error) {
+	problems := []string{}
+
+	for _, v := range pod.Spec.Volumes {
+		var obj interface{}
+		name := ""
+		kind := ""
+
+		if v.PersistentVolumeClaim != nil {
+			kind, name = "PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName
+			obj = &corev1.PersistentVolumeClaim{}
+		} else if v.ConfigMap != nil && v.ConfigMap.Name != cr.Name+"-config" &&
+			(v.ConfigMap.Optional == nil || !*v.ConfigMap.Optional) {
+			kind, name = "ConfigMap", v.ConfigMap.Name
+			obj = &corev1.ConfigMap{}
+		} else {
+			continue
+		}
+
+		var err error
+		switch o := obj.(type) {
+		case *corev1.PersistentVolumeClaim:
+			err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, o)
+		case *corev1.ConfigMap:
+			err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, o)
+		}
+
+		if errors.IsNotFound(err) {
+			problems = append(problems, fmt.Sprintf("%s %s of volume %s does not exist", kind, name, v.Name))
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return problems, nil
+}
+
+// holdWorkflow renders the primary pod of a Workflow in the <name>-config ConfigMap without creating it
+// and moves the Workflow to the Held phase. renderErr is the error of rendering the pod. It returns false
+// if the primary pod already exists because spec.hold does not affect Workflows that have started.
+func (r *WorkflowReconciler) holdWorkflow(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow, pod *corev1.Pod, renderErr error) (bool, error) {
+	found := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, found)
+	if err == nil {
+		return false, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	if renderErr != nil {
+		return true, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowHeld, st4sdv1alpha1.ReasonPreflightFailed,
+			"unable to render the pod: "+renderErr.Error())
+	}
+
+	problems, err := r.preflightChecks(ctx, instance, pod)
+	if err != nil {
+		return true, err
+	}
+
+	configYaml, err := yaml.Marshal(pod)
+	if err != nil {
+		return true, err
+	}
+
+	configMap := newConfigMap(instance, string(configYaml))
+	if err := controllerutil.SetControllerReference(instance, configMap, r.Scheme); err != nil {
+		return true, err
+	}
+
+	foundConfigMap := &corev1.ConfigMap{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, foundConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating config map", "Config.Namespace", configMap.Namespace, "Config.Name", configMap.Name)
+		if err := r.Client.Create(ctx, configMap); err != nil {
+			return true, err
+		}
+	} else if err != nil {
+		return true, err
+	} else if !equalStringMaps(foundConfigMap.Data, configMap.Data) {
+		// VV: The spec of a held Workflow may change until the hold is released
+		foundConfigMap.Data = configMap.Data
+		if err := r.Client.Update(ctx, foundConfigMap); err != nil {
+			return true, err
+		}
+	}
+
+	// VV: Persist the default values that newPodForCR filled in so that reviewers see the resolved spec
+	if err := r.Client.Update(ctx, instance); err != nil {
+		return true, err
+	}
+
+	if len(problems) > 0 {
+		return true, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowHeld, st4sdv1alpha1.ReasonPreflightFailed,
+			strings.Join(problems, ", "))
+	}
+
+	return true, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowHeld, st4sdv1alpha1.ReasonHeld,
+		"the workflow is on hold, the pod "+pod.Name+" is rendered in the ConfigMap "+configMap.Name)
+}
+
+func equalStringMaps(one map[string]string, other map[string]string) bool {
+	if len(one) != len(other) {
+		return false
+	}
+	for k, v := range one {
+		if value, ok := other[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestHold tests that the operator renders the pod of a held Workflow without creating it, reports
+// missing volumes, and creates the pod after the hold is released
+func TestHold(t *testing.T) {
+	wf := newTestWorkflow("hold")
+	wf.Spec.Hold = true
+	wf.Spec.WorkingVolume = corev1.Volume{
+		Name: "working-volume",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "workdir-pvc"},
+		},
+	}
+
+	gitSyncConfig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "git-sync-config", Namespace: "default"}}
+	r := newTestReconciler(wf, gitSyncConfig)
+	ctx := context.TODO()
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowHeld || wf.Status.Reason != st4sdv1alpha1.ReasonPreflightFailed {
+		t.Fatal("Expected the preflight checks to fail", "status", wf.Status)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "workdir-pvc", Namespace: "default"}}
+	if err := r.Create(ctx, pvc); err != nil {
+		t.Fatal("Unable to create PersistentVolumeClaim", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowHeld || wf.Status.Reason != st4sdv1alpha1.ReasonHeld {
+		t.Fatal("Expected the Workflow to be held", "status", wf.Status)
+	}
+	if getTestPod(r, wf.Name) != nil {
+		t.Fatal("Did not expect a pod for the held Workflow")
+	}
+
+	config := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Name: wf.Name + "-config", Namespace: "default"}, config); err != nil {
+		t.Fatal("Expected the ConfigMap with the rendered pod", err)
+	}
+	if config.Data["flow-k8s-conf.yml"] == "" {
+		t.Error("Expected the rendered pod in the ConfigMap", "data", config.Data)
+	}
+
+	wf.Spec.Hold = false
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) == nil {
+		t.Fatal("Expected a pod after releasing the hold", "status", getTestWorkflow(t, r, wf.Name).Status)
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected the released Workflow to run", "status", wf.Status)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+const (
+	// kueueQueueNameLabel is the label with the LocalQueue that Kueue admits a pod to
+	kueueQueueNameLabel = "kueue.x-k8s.io/queue-name"
+	// kueueAdmissionGate is the scheduling gate that Kueue removes from a pod after it admits it
+	kueueAdmissionGate = "kueue.x-k8s.io/admission"
+)
+
+// applyKueue creates the primary pod of a Workflow with spec.queueName suspended until Kueue admits it
+func applyKueue(cr *st4sdv1alpha1.Workflow, pod *corev1.Pod) {
+	if cr.Spec.QueueName == "" {
+		return
+	}
+
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[kueueQueueNameLabel] = cr.Spec.QueueName
+
+	if !kueueGated(pod) {
+		pod.Spec.SchedulingGates = append(pod.Spec.SchedulingGates,
+			corev1.PodSchedulingGate{Name: kueueAdmissionGate})
+	}
+}
+
+// kueueGated returns true if Kueue has not admitted a pod yet
+func kueueGated(pod *corev1.Pod) bool {
+	for _, gate := range pod.Spec.SchedulingGates {
+		if gate.Name == kueueAdmissionGate {
+			return true
+		}
+	}
+	return false
+}
+
+// kueueMessage explains that a Workflow is waiting for Kueue to admit its primary pod
+func kueueMessage(cr *st4sdv1alpha1.Workflow) string {
+	return "waiting for Kueue to admit the pod " + primaryPodName(cr) + " to the LocalQueue " + cr.Spec.QueueName
+}
@@ -0,0 +1,77 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestKueueAdmission tests that the operator creates the pod of a Workflow with spec.queueName
+// suspended and that the Workflow runs after Kueue admits the pod
+func TestKueueAdmission(t *testing.T) {
+	// VV: Kueue manages the quota of the Workflow instead of the operator's admission queue
+	t.Setenv("MAX_CONCURRENT_WORKFLOWS", "0")
+
+	wf := newTestWorkflow("kueue")
+	wf.Spec.QueueName = "team-a"
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	pod := getTestPod(r, wf.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod", "status", getTestWorkflow(t, r, wf.Name).Status)
+	}
+	if pod.Labels[kueueQueueNameLabel] != "team-a" || !kueueGated(pod) {
+		t.Fatal("Expected the pod to wait for Kueue", "labels", pod.Labels, "gates", pod.Spec.SchedulingGates)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowQueued || wf.Status.Reason != st4sdv1alpha1.ReasonWaitingForKueue {
+		t.Fatal("Expected the Workflow to wait for Kueue", "status", wf.Status)
+	}
+
+	pod.Spec.SchedulingGates = nil
+	if err := r.Update(context.TODO(), pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected the admitted Workflow to run", "status", wf.Status)
+	}
+}
+
+// TestKueueCancel tests that cancelling a Workflow deletes its pod if Kueue has not admitted it yet
+func TestKueueCancel(t *testing.T) {
+	wf := newTestWorkflow("kueue-cancel")
+	wf.Spec.QueueName = "team-a"
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	wf.Spec.Cancel = true
+	if err := r.Update(context.TODO(), wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Expected the unadmitted pod to be deleted")
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowCancelled {
+		t.Error("Expected the Workflow to be cancelled", "status", wf.Status)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// phaseIsFinal returns true for the phases of Workflows that will not make any more progress
+func phaseIsFinal(phase st4sdv1alpha1.WorkflowPhase) bool {
+	switch phase {
+	case st4sdv1alpha1.WorkflowSucceeded, st4sdv1alpha1.WorkflowFailed, st4sdv1alpha1.WorkflowCancelled:
+		return true
+	}
+	return false
+}
+
+// setPhase updates the phase, reason, and message of a Workflow if they changed.
+// Entering the Failed phase also creates a Warning Event.
+func (r *WorkflowReconciler) setPhase(ctx context.Context, instance *st4sdv1alpha1.Workflow,
+	phase st4sdv1alpha1.WorkflowPhase, reason string, message string) error {
+	if instance.Status.Phase == phase && instance.Status.Reason == reason && instance.Status.Message == message {
+		return nil
+	}
+
+	if phaseIsFinal(phase) && !phaseIsFinal(instance.Status.Phase) {
+		now := metav1.Now()
+		instance.Status.CompletionTime = &now
+	}
+
+	instance.Status.Phase = phase
+	instance.Status.Reason = reason
+	instance.Status.Message = message
+
+	if err := r.Client.Update(ctx, instance); err != nil {
+		return err
+	}
+
+	if phase == st4sdv1alpha1.WorkflowFailed {
+		event := newWorkflowEvent(instance, "Warning", reason, message)
+		if err := r.Client.Create(ctx, event); err != nil {
+			log.FromContext(ctx).Error(err, "Error in creating event")
+		}
+	}
+
+	return nil
+}
+
+// syncPhase updates the phase of a Workflow using the status that the monitoring side-container reports
+// and the state of the primary pod. The Succeeded, Failed, and Cancelled phases are final.
+func (r *WorkflowReconciler) syncPhase(ctx context.Context, instance *st4sdv1alpha1.Workflow) error {
+	if phaseIsFinal(instance.Status.Phase) {
+		return nil
+	}
+
+	if instance.Status.Succeeded() {
+		return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowSucceeded, "", "")
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	podExists := err == nil
+
+	// VV: The orchestrator also fails when the kubelet stops it, report the root cause
+	if podExists && pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == st4sdv1alpha1.ReasonDeadlineExceeded {
+		message := "the workflow did not finish within its deadline"
+		if instance.Spec.Timeout != nil {
+			message = "the workflow did not finish within spec.timeout " + instance.Spec.Timeout.Duration.String()
+		}
+		return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed, st4sdv1alpha1.ReasonDeadlineExceeded, message)
+	}
+
+	if instance.Status.Failed() {
+		return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed,
+			st4sdv1alpha1.ReasonOrchestratorFailed, instance.Status.Errordescription)
+	}
+
+	if !podExists {
+		return nil
+	}
+
+	if pod.Status.Phase == corev1.PodFailed {
+		message := pod.Status.Message
+		if message == "" {
+			message = "the pod " + pod.Name + " failed"
+		}
+		return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed, st4sdv1alpha1.ReasonPodFailed, message)
+	}
+
+	if kueueGated(pod) {
+		return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowQueued, st4sdv1alpha1.ReasonWaitingForKueue,
+			kueueMessage(instance))
+	}
+
+	return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowRunning, "", "")
+}
@@ -0,0 +1,162 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// podTemplateError is an invalid spec.podTemplate, the operator does not create the pod of the Workflow
+type podTemplateError struct {
+	message string
+}
+
+func (e *podTemplateError) Error() string {
+	return "invalid spec.podTemplate: " + e.message
+}
+
+func newPodTemplateError(format string, args ...interface{}) error {
+	return &podTemplateError{message: fmt.Sprintf(format, args...)}
+}
+
+// applyPodTemplate strategic-merge-patches spec.podTemplate of a Workflow onto its primary pod.
+// It returns a podTemplateError if the template overrides anything that the operator owns.
+func applyPodTemplate(cr *st4sdv1alpha1.Workflow, pod *corev1.Pod) error {
+	template := cr.Spec.PodTemplate
+	if template == nil {
+		return nil
+	}
+
+	for key, value := range template.Metadata.Labels {
+		if current, ok := pod.Labels[key]; ok && current != value {
+			return newPodTemplateError("the label %s belongs to the operator", key)
+		}
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[key] = value
+	}
+
+	for key, value := range template.Metadata.Annotations {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[key] = value
+	}
+
+	if template.Spec == nil || len(template.Spec.Raw) == 0 {
+		return nil
+	}
+
+	original, err := json.Marshal(&pod.Spec)
+	if err != nil {
+		return err
+	}
+
+	patched, err := strategicpatch.StrategicMergePatch(original, template.Spec.Raw, corev1.PodSpec{})
+	if err != nil {
+		return newPodTemplateError("%s", err.Error())
+	}
+
+	// VV: Reject unknown fields so that typos do not go unnoticed
+	spec := corev1.PodSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return newPodTemplateError("%s", err.Error())
+	}
+
+	if err := checkPodSpecOverrides(&pod.Spec, &spec); err != nil {
+		return err
+	}
+
+	pod.Spec = spec
+	return nil
+}
+
+// checkPodSpecOverrides returns a podTemplateError if patched changes a part of original that the operator
+// owns: its volumes, the containers and their command, args, and volume mounts, the restart policy, the
+// activeDeadlineSeconds (spec.timeout and cancelling), the scheduling gates (Kueue), the service account,
+// and the security context. Patches may add volumes, containers, and volume mounts.
+func checkPodSpecOverrides(original *corev1.PodSpec, patched *corev1.PodSpec) error {
+	if original.RestartPolicy != patched.RestartPolicy {
+		return newPodTemplateError("the restartPolicy of the pod must be %s", original.RestartPolicy)
+	}
+	if !equality.Semantic.DeepEqual(original.ActiveDeadlineSeconds, patched.ActiveDeadlineSeconds) {
+		return newPodTemplateError("the activeDeadlineSeconds of the pod belongs to the operator, use spec.timeout")
+	}
+	if !equality.Semantic.DeepEqual(original.SchedulingGates, patched.SchedulingGates) {
+		return newPodTemplateError("the schedulingGates of the pod belong to the operator")
+	}
+	if original.ServiceAccountName != patched.ServiceAccountName {
+		return newPodTemplateError("the serviceAccountName of the pod belongs to the operator")
+	}
+	if !equality.Semantic.DeepEqual(original.SecurityContext, patched.SecurityContext) {
+		return newPodTemplateError("the securityContext of the pod belongs to the operator")
+	}
+
+	volumes := map[string]*corev1.Volume{}
+	for i := range patched.Volumes {
+		volumes[patched.Volumes[i].Name] = &patched.Volumes[i]
+	}
+	for i := range original.Volumes {
+		volume := &original.Volumes[i]
+		if other, ok := volumes[volume.Name]; !ok || !equality.Semantic.DeepEqual(volume, other) {
+			return newPodTemplateError("the volume %s belongs to the operator", volume.Name)
+		}
+	}
+
+	if err := checkContainerOverrides(original.InitContainers, patched.InitContainers); err != nil {
+		return err
+	}
+	return checkContainerOverrides(original.Containers, patched.Containers)
+}
+
+func checkContainerOverrides(original []corev1.Container, patched []corev1.Container) error {
+	containers := map[string]*corev1.Container{}
+	for i := range patched {
+		containers[patched[i].Name] = &patched[i]
+	}
+
+	for i := range original {
+		container := &original[i]
+		other, ok := containers[container.Name]
+		if !ok {
+			return newPodTemplateError("the container %s belongs to the operator and cannot be removed",
+				container.Name)
+		}
+		if !equality.Semantic.DeepEqual(container.Command, other.Command) ||
+			!equality.Semantic.DeepEqual(container.Args, other.Args) {
+			return newPodTemplateError("the command of the container %s belongs to the operator", container.Name)
+		}
+
+		mounts := map[string]*corev1.VolumeMount{}
+		for j := range other.VolumeMounts {
+			mounts[other.VolumeMounts[j].MountPath] = &other.VolumeMounts[j]
+		}
+		for j := range container.VolumeMounts {
+			mount := &container.VolumeMounts[j]
+			if otherMount, ok := mounts[mount.MountPath]; !ok || !equality.Semantic.DeepEqual(mount, otherMount) {
+				return newPodTemplateError("the volume mount %s of the container %s belongs to the operator",
+					mount.MountPath, container.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestPodTemplate tests that the operator patches spec.podTemplate onto the pod of a Workflow
+// and matches containers by name
+func TestPodTemplate(t *testing.T) {
+	wf := newTestWorkflow("pod-template")
+	wf.Spec.PodTemplate = &st4sdv1alpha1.PodTemplateOverlay{
+		Metadata: st4sdv1alpha1.PodTemplateMetadata{
+			Labels:      map[string]string{"team": "chemistry"},
+			Annotations: map[string]string{"example.com/cost-center": "42"},
+		},
+		Spec: &runtime.RawExtension{Raw: []byte(`{
+			"hostname": "orchestrator",
+			"volumes": [{"name": "scratch", "emptyDir": {}}],
+			"containers": [{
+				"name": "elaunch-primary",
+				"env": [{"name": "EXTRA", "value": "yes"}],
+				"volumeMounts": [{"name": "scratch", "mountPath": "/scratch"}]
+			}]
+		}`)},
+	}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	if pod.Labels["team"] != "chemistry" || pod.Labels["workflow"] != wf.Name {
+		t.Error("Unexpected labels", "labels", pod.Labels)
+	}
+	if pod.Annotations["example.com/cost-center"] != "42" {
+		t.Error("Unexpected annotations", "annotations", pod.Annotations)
+	}
+	if pod.Spec.Hostname != "orchestrator" {
+		t.Error("Expected the hostname of the template", "hostname", pod.Spec.Hostname)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if primary == nil || len(primary.Command) == 0 {
+		t.Fatal("Expected the command of elaunch-primary to remain", "container", primary)
+	}
+	foundEnv := false
+	for _, env := range primary.Env {
+		foundEnv = foundEnv || (env.Name == "EXTRA" && env.Value == "yes")
+	}
+	if !foundEnv {
+		t.Error("Expected the env var of the template", "env", primary.Env)
+	}
+	foundMount := false
+	for _, mount := range primary.VolumeMounts {
+		foundMount = foundMount || mount.MountPath == "/scratch"
+	}
+	if !foundMount || len(primary.VolumeMounts) < 2 {
+		t.Error("Expected the volume mount of the template", "volumeMounts", primary.VolumeMounts)
+	}
+	if findContainer(pod.Spec.Containers, "monitor-elaunch-container") == nil {
+		t.Error("Expected the monitor container to remain")
+	}
+}
+
+// TestPodTemplateForbidden tests that the operator rejects templates which override what it owns
+func TestPodTemplateForbidden(t *testing.T) {
+	templates := map[string]string{
+		"volume":   `{"volumes": [{"name": "working-volume", "emptyDir": {}}]}`,
+		"command":  `{"containers": [{"name": "elaunch-primary", "command": ["sleep", "inf"]}]}`,
+		"restart":  `{"restartPolicy": "Always"}`,
+		"typo":     `{"nodeSelectr": {"pool": "compute"}}`,
+		"deadline": `{"activeDeadlineSeconds": 60}`,
+		"gates":    `{"schedulingGates": [{"name": "example.com/gate"}]}`,
+		"account":  `{"serviceAccountName": "admin"}`,
+		"security": `{"securityContext": {"runAsUser": 1234}}`,
+	}
+
+	for name, template := range templates {
+		wf := newTestWorkflow("pod-template-" + name)
+		wf.Spec.PodTemplate = &st4sdv1alpha1.PodTemplateOverlay{Spec: &runtime.RawExtension{Raw: []byte(template)}}
+
+		if _, err := newPodForCR(newTestReconciler(), wf); err == nil || !strings.Contains(err.Error(), "spec.podTemplate") {
+			t.Error("Expected an invalid spec.podTemplate", "template", name, "err", err)
+		}
+	}
+
+	wf := newTestWorkflow("pod-template-label")
+	wf.Spec.PodTemplate = &st4sdv1alpha1.PodTemplateOverlay{
+		Metadata: st4sdv1alpha1.PodTemplateMetadata{Labels: map[string]string{"workflow": "other"}},
+	}
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowFailed || wf.Status.Reason != st4sdv1alpha1.ReasonInvalidPodTemplate {
+		t.Error("Expected the Workflow to fail", "status", wf.Status)
+	}
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Did not expect a pod")
+	}
+
+	// VV: The template must not remove the scheduling gate of Kueue
+	wf = newTestWorkflow("pod-template-kueue")
+	wf.Spec.QueueName = "user-queue"
+	wf.Spec.PodTemplate = &st4sdv1alpha1.PodTemplateOverlay{Spec: &runtime.RawExtension{Raw: []byte(
+		`{"schedulingGates": [{"name": "` + kueueAdmissionGate + `", "$patch": "delete"}]}`)}}
+	if _, err := newPodForCR(newTestReconciler(), wf); err == nil || !strings.Contains(err.Error(), "schedulingGates") {
+		t.Error("Expected removing the Kueue scheduling gate to be forbidden", "err", err)
+	}
+
+	// VV: The operator owns the volumes of the pod, the template may only add new ones
+	if err := checkPodSpecOverrides(&corev1.PodSpec{Volumes: []corev1.Volume{{Name: "a"}}},
+		&corev1.PodSpec{}); err == nil {
+		t.Error("Expected removing a volume to be forbidden")
+	}
+}
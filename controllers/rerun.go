@@ -0,0 +1,95 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// rerunAnnotation is the annotation which triggers a new run of a Workflow when its value changes
+const rerunAnnotation = "st4sd.ibm.com/rerun"
+
+// maxHistory is the number of previous runs that status.history keeps
+const maxHistory = 10
+
+// rerunWorkflow archives the status of the current run of a Workflow in status.history and resets the
+// status so that the operator creates the pod of a new run. It stops the primary pod of the current
+// run first and returns false while waiting for it to terminate, then deletes the pods and jobs that
+// the orchestrator of the current run created.
+func (r *WorkflowReconciler) rerunWorkflow(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow, token string) (bool, error) {
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	podExists := err == nil
+
+	if podExists && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		// VV: The monitoring side-container of the old pod must not update the status of the new run
+		return false, r.stopPod(ctx, reqLogger, pod)
+	}
+
+	if !podExists && !phaseIsFinal(instance.Status.Phase) {
+		// VV: The current run has not started yet
+		instance.Status.ObservedRerun = token
+		return true, r.Client.Update(ctx, instance)
+	}
+
+	// VV: The pods and jobs of the old run would count towards status.childResources of the new run
+	pods, jobs, err := r.listChildResources(ctx, instance)
+	if err != nil {
+		return false, err
+	}
+	if _, _, err := r.deleteChildResources(ctx, reqLogger, pods, jobs); err != nil {
+		return false, err
+	}
+
+	history := append(instance.Status.History, st4sdv1alpha1.WorkflowRun{
+		Run:              instance.Status.Run,
+		Rerun:            instance.Status.ObservedRerun,
+		Pod:              primaryPodName(instance),
+		Phase:            instance.Status.Phase,
+		Reason:           instance.Status.Reason,
+		Message:          instance.Status.Message,
+		Experimentstate:  instance.Status.Experimentstate,
+		Exitstatus:       instance.Status.Exitstatus,
+		Errordescription: instance.Status.Errordescription,
+		InstanceName:     instance.Status.InstanceName,
+		CompletionTime:   instance.Status.CompletionTime,
+		Outputfiles:      instance.Status.Outputfiles,
+	})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+
+	instance.Status = st4sdv1alpha1.WorkflowStatus{
+		Run:                   instance.Status.Run + 1,
+		ObservedRerun:         token,
+		History:               history,
+		CompletedDependencies: instance.Status.CompletedDependencies,
+	}
+
+	reqLogger.Info("Rerunning Workflow", "run", instance.Status.Run, "rerun", token)
+	event := newWorkflowEvent(instance, "Normal", "Rerun", "Starting run "+primaryPodName(instance)+
+		" because "+rerunAnnotation+" is "+token)
+	if err := r.Client.Create(ctx, event); err != nil {
+		reqLogger.Error(err, "Error in creating event")
+	}
+
+	return true, r.Client.Update(ctx, instance)
+}
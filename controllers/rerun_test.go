@@ -0,0 +1,72 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestRerun tests that changing the rerun annotation deletes the leftovers of a finished Workflow,
+// archives its status, and creates the pod of a new run
+func TestRerun(t *testing.T) {
+	wf := newTestWorkflow("rerun")
+	wf.Annotations = map[string]string{rerunAnnotation: "initial"}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.ObservedRerun != "initial" || len(wf.Status.History) != 0 {
+		t.Fatal("Did not expect the initial annotation to archive anything", "status", wf.Status)
+	}
+
+	finishTestWorkflow(t, r, wf.Name, time.Minute)
+	succeedTestPod(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	// VV: A pod of the orchestrator which the operator has not cleaned up yet
+	leftover := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rerun-component", Namespace: "default",
+		Labels: map[string]string{"workflow": "rerun"}}}
+	if err := r.Create(ctx, leftover); err != nil {
+		t.Fatal("Unable to create pod", err)
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	wf.Annotations[rerunAnnotation] = "again"
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Run != 1 || wf.Status.ObservedRerun != "again" || wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected a new run", "status", wf.Status)
+	}
+	if len(wf.Status.History) != 1 || wf.Status.History[0].Pod != "rerun" ||
+		wf.Status.History[0].Phase != st4sdv1alpha1.WorkflowSucceeded || wf.Status.History[0].Rerun != "initial" {
+		t.Error("Expected the previous run in the history", "history", wf.Status.History)
+	}
+	if getTestPod(r, "rerun-run-1") == nil {
+		t.Error("Expected the pod rerun-run-1")
+	}
+	if getTestPod(r, leftover.Name) != nil {
+		t.Error("Expected the leftover pod of the previous run to be deleted")
+	}
+	if wf.Status.ChildResources != nil {
+		t.Error("Did not expect the new run to have child resources", "childResources", wf.Status.ChildResources)
+	}
+}
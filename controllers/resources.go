@@ -0,0 +1,206 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// defaultResourceProfile is the resource profile in config.json that every Workflow starts from, it
+// overrides builtinResources
+const defaultResourceProfile = "default"
+
+// builtinResources are the resources of the containers in the primary pod when config.json does not
+// have a default resource profile
+var builtinResources = st4sdv1alpha1.Resourcespec{
+	ElaunchPrimary: &st4sdv1alpha1.Resourcedefinition{Cpu: "1000m", Memory: "500Mi"},
+	Monitor:        &st4sdv1alpha1.Resourcedefinition{Cpu: "100m", Memory: "200Mi"},
+	GitFetch:       &st4sdv1alpha1.Resourcedefinition{Cpu: "100m", Memory: "200Mi"},
+	S3Fetch:        &st4sdv1alpha1.Resourcedefinition{Cpu: "100m", Memory: "200Mi"},
+}
+
+// resourcesError is an invalid spec.resources entry, the operator does not create the pod of the Workflow
+type resourcesError struct {
+	message string
+}
+
+func (e *resourcesError) Error() string {
+	return "invalid spec.resources." + e.message
+}
+
+func newResourcesError(format string, args ...interface{}) error {
+	return &resourcesError{message: fmt.Sprintf(format, args...)}
+}
+
+// resourceProfileError is a spec.resources.profile which config.json does not define
+type resourceProfileError struct {
+	profile   string
+	available []string
+}
+
+func (e *resourceProfileError) Error() string {
+	if len(e.available) == 0 {
+		return fmt.Sprintf("unknown spec.resources.profile %q, there are no resource profiles", e.profile)
+	}
+	return fmt.Sprintf("unknown spec.resources.profile %q, the resource profiles are %s", e.profile,
+		strings.Join(e.available, ", "))
+}
+
+// mergeResourceList returns the resources of base with those of override replacing them
+func mergeResourceList(base corev1.ResourceList, override corev1.ResourceList) corev1.ResourceList {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := base.DeepCopy()
+	if merged == nil {
+		merged = corev1.ResourceList{}
+	}
+	for name, quantity := range override {
+		merged[name] = quantity.DeepCopy()
+	}
+	return merged
+}
+
+// mergeResourcedefinition returns base with the fields that override sets replacing those of base
+func mergeResourcedefinition(base *st4sdv1alpha1.Resourcedefinition,
+	override *st4sdv1alpha1.Resourcedefinition) *st4sdv1alpha1.Resourcedefinition {
+	if override == nil {
+		return base.DeepCopy()
+	}
+	if base == nil {
+		return override.DeepCopy()
+	}
+
+	merged := base.DeepCopy()
+
+	// VV: A shorthand in override also replaces the request and limit of the resource in base
+	for name, value := range map[corev1.ResourceName]string{
+		corev1.ResourceCPU:              override.Cpu,
+		corev1.ResourceMemory:           override.Memory,
+		corev1.ResourceEphemeralStorage: override.EphemeralStorage,
+	} {
+		if len(value) > 0 {
+			delete(merged.Requests, name)
+			delete(merged.Limits, name)
+		}
+	}
+	if len(override.Cpu) > 0 {
+		merged.Cpu = override.Cpu
+	}
+	if len(override.Memory) > 0 {
+		merged.Memory = override.Memory
+	}
+	if len(override.EphemeralStorage) > 0 {
+		merged.EphemeralStorage = override.EphemeralStorage
+	}
+	merged.Requests = mergeResourceList(merged.Requests, override.Requests)
+	merged.Limits = mergeResourceList(merged.Limits, override.Limits)
+	return merged
+}
+
+// mergeResourcespec merges the resources of each container in override into those of base
+func mergeResourcespec(base *st4sdv1alpha1.Resourcespec, override *st4sdv1alpha1.Resourcespec) *st4sdv1alpha1.Resourcespec {
+	return &st4sdv1alpha1.Resourcespec{
+		ElaunchPrimary: mergeResourcedefinition(base.ElaunchPrimary, override.ElaunchPrimary),
+		Monitor:        mergeResourcedefinition(base.Monitor, override.Monitor),
+		GitFetch:       mergeResourcedefinition(base.GitFetch, override.GitFetch),
+		S3Fetch:        mergeResourcedefinition(base.S3Fetch, override.S3Fetch),
+	}
+}
+
+// containerResources returns the resources of the containers in the primary pod of a Workflow. These are
+// builtinResources, overridden by the default resource profile, then the profile that spec.resources.profile
+// selects, and then the containers in spec.resources
+func containerResources(cr *st4sdv1alpha1.Workflow,
+	options *st4sdv1alpha1.DefaultWorkflowOptions) (*st4sdv1alpha1.Resourcespec, error) {
+	resources := builtinResources.DeepCopy()
+
+	if profile, ok := options.ResourceProfiles[defaultResourceProfile]; ok {
+		resources = mergeResourcespec(resources, &profile)
+	}
+
+	if cr.Spec.Resources == nil {
+		return resources, nil
+	}
+
+	if name := cr.Spec.Resources.Profile; len(name) > 0 {
+		profile, ok := options.ResourceProfiles[name]
+		if !ok {
+			available := []string{}
+			for v := range options.ResourceProfiles {
+				available = append(available, v)
+			}
+			sort.Strings(available)
+			return nil, &resourceProfileError{profile: name, available: available}
+		}
+		resources = mergeResourcespec(resources, &profile)
+	}
+
+	return mergeResourcespec(resources, cr.Spec.Resources), nil
+}
+
+// resourceRequirements returns the requests and limits of a container. Without requests and limits in
+// definition, the shorthands (cpu, memory, ephemeralStorage) are both the requests and the limits of the
+// container, i.e. Guaranteed QoS. Otherwise, the shorthands only fill in the requests of resources that
+// have neither a request nor a limit.
+func resourceRequirements(definition *st4sdv1alpha1.Resourcedefinition) (corev1.ResourceRequirements, error) {
+	shorthands := corev1.ResourceList{}
+
+	if definition != nil {
+		for name, value := range map[corev1.ResourceName]string{
+			corev1.ResourceCPU:              definition.Cpu,
+			corev1.ResourceMemory:           definition.Memory,
+			corev1.ResourceEphemeralStorage: definition.EphemeralStorage,
+		} {
+			if len(value) == 0 {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return corev1.ResourceRequirements{}, fmt.Errorf("invalid %s %q: %v", name, value, err)
+			}
+			shorthands[name] = quantity
+		}
+	}
+
+	if definition == nil || (len(definition.Requests) == 0 && len(definition.Limits) == 0) {
+		return corev1.ResourceRequirements{Requests: shorthands, Limits: shorthands.DeepCopy()}, nil
+	}
+
+	requests := definition.Requests.DeepCopy()
+	if requests == nil {
+		requests = corev1.ResourceList{}
+	}
+	limits := definition.Limits.DeepCopy()
+
+	for name, quantity := range shorthands {
+		_, hasRequest := requests[name]
+		_, hasLimit := limits[name]
+		if !hasRequest && !hasLimit {
+			requests[name] = quantity
+		}
+	}
+
+	for name, request := range requests {
+		if limit, ok := limits[name]; ok && request.Cmp(limit) > 0 {
+			return corev1.ResourceRequirements{}, fmt.Errorf("the request %s of %s is larger than its limit %s",
+				request.String(), name, limit.String())
+		}
+	}
+
+	return corev1.ResourceRequirements{Requests: requests, Limits: limits}, nil
+}
@@ -0,0 +1,192 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+func expectQuantity(t *testing.T, what string, resources corev1.ResourceList, name corev1.ResourceName, expected string) {
+	t.Helper()
+	quantity, ok := resources[name]
+	if expected == "" {
+		if ok {
+			t.Error("Did not expect", what, name, "got", quantity.String())
+		}
+	} else if !ok || quantity.Cmp(resource.MustParse(expected)) != 0 {
+		t.Error("Expected", what, name, expected, "got", quantity.String())
+	}
+}
+
+// TestResourceRequirements tests that requests and limits default to the shorthands only when neither is set
+func TestResourceRequirements(t *testing.T) {
+	requirements, err := resourceRequirements(&st4sdv1alpha1.Resourcedefinition{Cpu: "2", Memory: "500Mi"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expectQuantity(t, "request", requirements.Requests, corev1.ResourceCPU, "2")
+	expectQuantity(t, "limit", requirements.Limits, corev1.ResourceCPU, "2")
+	expectQuantity(t, "limit", requirements.Limits, corev1.ResourceMemory, "500Mi")
+
+	requirements, err = resourceRequirements(&st4sdv1alpha1.Resourcedefinition{
+		Cpu:              "1",
+		Memory:           "500Mi",
+		EphemeralStorage: "10Gi",
+		Requests:         corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory:                 resource.MustParse("4Gi"),
+			corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("1"),
+		},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expectQuantity(t, "request", requirements.Requests, corev1.ResourceCPU, "2")
+	expectQuantity(t, "request", requirements.Requests, corev1.ResourceMemory, "")
+	expectQuantity(t, "request", requirements.Requests, corev1.ResourceEphemeralStorage, "10Gi")
+	expectQuantity(t, "limit", requirements.Limits, corev1.ResourceCPU, "")
+	expectQuantity(t, "limit", requirements.Limits, corev1.ResourceMemory, "4Gi")
+	expectQuantity(t, "limit", requirements.Limits, "nvidia.com/gpu", "1")
+
+	_, err = resourceRequirements(&st4sdv1alpha1.Resourcedefinition{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	})
+	if err == nil {
+		t.Error("Expected a request larger than its limit to be invalid")
+	}
+
+	if _, err = resourceRequirements(&st4sdv1alpha1.Resourcedefinition{Cpu: "lots"}); err == nil {
+		t.Error("Expected an invalid cpu quantity to be invalid")
+	}
+}
+
+// TestNewPodForCRS3FetchResources tests that the s3-fetch init-container does not use the resources of gitFetch
+func TestNewPodForCRS3FetchResources(t *testing.T) {
+	wf := newTestWorkflow("s3-fetch-resources")
+	wf.Spec.S3BucketInput = &st4sdv1alpha1.S3BucketInputInfo{
+		DatashimS3BucketInfo: st4sdv1alpha1.DatashimS3BucketInfo{Dataset: "my-dataset"},
+	}
+	wf.Spec.Resources = &st4sdv1alpha1.Resourcespec{
+		GitFetch: &st4sdv1alpha1.Resourcedefinition{Cpu: "300m"},
+		S3Fetch:  &st4sdv1alpha1.Resourcedefinition{Memory: "2Gi", EphemeralStorage: "20Gi"},
+	}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	gitSync := findContainer(pod.Spec.InitContainers, "git-sync-package")
+	s3Fetch := findContainer(pod.Spec.InitContainers, "s3-fetch")
+	if gitSync == nil || s3Fetch == nil {
+		t.Fatal("Expected git-sync-package and s3-fetch init-containers")
+	}
+
+	expectQuantity(t, "git-sync-package request", gitSync.Resources.Requests, corev1.ResourceCPU, "300m")
+	expectQuantity(t, "s3-fetch request", s3Fetch.Resources.Requests, corev1.ResourceCPU, "100m")
+	expectQuantity(t, "s3-fetch limit", s3Fetch.Resources.Limits, corev1.ResourceMemory, "2Gi")
+	expectQuantity(t, "s3-fetch limit", s3Fetch.Resources.Limits, corev1.ResourceEphemeralStorage, "20Gi")
+}
+
+// TestInvalidResources tests that Workflows with invalid quantities or requests larger than their limits fail
+func TestInvalidResources(t *testing.T) {
+	invalid := map[string]*st4sdv1alpha1.Resourcespec{
+		"invalid-quantity": {ElaunchPrimary: &st4sdv1alpha1.Resourcedefinition{Cpu: "lots"}},
+		"request-over-limit": {Monitor: &st4sdv1alpha1.Resourcedefinition{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		}},
+	}
+
+	for name, resources := range invalid {
+		wf := newTestWorkflow(name)
+		wf.Spec.Resources = resources
+
+		r := newTestReconciler(wf)
+		reconcileTestWorkflow(t, r, wf.Name)
+
+		wf = getTestWorkflow(t, r, wf.Name)
+		if wf.Status.Phase != st4sdv1alpha1.WorkflowFailed || wf.Status.Reason != st4sdv1alpha1.ReasonInvalidResources ||
+			wf.Status.Message == "" {
+			t.Error("Expected the Workflow to fail with InvalidResources", "name", name, "status", wf.Status)
+		}
+		if getTestPod(r, wf.Name) != nil {
+			t.Error("Did not expect a pod", "name", name)
+		}
+	}
+}
+
+// TestResourceProfiles tests that the resources of a Workflow override those of its profile, which override
+// those of the default profile
+func TestResourceProfiles(t *testing.T) {
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName(), Namespace: "default"},
+		Data: map[string]string{"config.json": `{"profiles": {
+			"default": {"elaunchPrimary": {"cpu": "500m", "memory": "1Gi"}},
+			"large-orchestrator": {
+				"elaunchPrimary": {"cpu": "4", "memory": "8Gi"},
+				"monitor": {"requests": {"cpu": "50m"}, "limits": {"memory": "100Mi"}}
+			}
+		}}`},
+	}
+	r := newTestReconciler(config)
+
+	wf := newTestWorkflow("profile-default")
+	pod, err := newPodForCR(r, wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	expectQuantity(t, "default elaunch-primary limit", primary.Resources.Limits, corev1.ResourceCPU, "500m")
+	monitor := findContainer(pod.Spec.Containers, "monitor-elaunch-container")
+	expectQuantity(t, "default monitor limit", monitor.Resources.Limits, corev1.ResourceCPU, "100m")
+
+	wf = newTestWorkflow("profile-large")
+	wf.Spec.Resources = &st4sdv1alpha1.Resourcespec{
+		Profile:        "large-orchestrator",
+		ElaunchPrimary: &st4sdv1alpha1.Resourcedefinition{Memory: "16Gi"},
+	}
+	pod, err = newPodForCR(r, wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+	primary = findContainer(pod.Spec.Containers, "elaunch-primary")
+	expectQuantity(t, "elaunch-primary limit", primary.Resources.Limits, corev1.ResourceCPU, "4")
+	expectQuantity(t, "elaunch-primary limit", primary.Resources.Limits, corev1.ResourceMemory, "16Gi")
+	monitor = findContainer(pod.Spec.Containers, "monitor-elaunch-container")
+	expectQuantity(t, "monitor request", monitor.Resources.Requests, corev1.ResourceCPU, "50m")
+	expectQuantity(t, "monitor limit", monitor.Resources.Limits, corev1.ResourceCPU, "")
+	expectQuantity(t, "monitor limit", monitor.Resources.Limits, corev1.ResourceMemory, "100Mi")
+
+	wf = newTestWorkflow("profile-unknown")
+	wf.Spec.Resources = &st4sdv1alpha1.Resourcespec{Profile: "huge"}
+	if err := r.Create(context.TODO(), wf); err != nil {
+		t.Fatal("Unable to create Workflow", err)
+	}
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	expected := `unknown spec.resources.profile "huge", the resource profiles are default, large-orchestrator`
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonUnknownResourceProfile || wf.Status.Message != expected {
+		t.Error("Expected the Workflow to fail with UnknownResourceProfile", "status", wf.Status)
+	}
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Did not expect a pod for a Workflow with an unknown profile")
+	}
+}
@@ -0,0 +1,84 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// applyRestartFrom points status.restart of a Workflow to the instance directory of the Workflow in
+// spec.restartFrom and fills in the unset spec fields of the Workflow with the resolved settings of
+// the earlier Workflow. It returns a non-empty message if the Workflow cannot restart.
+func (r *WorkflowReconciler) applyRestartFrom(ctx context.Context, cr *st4sdv1alpha1.Workflow) (string, error) {
+	earlier := &st4sdv1alpha1.Workflow{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.RestartFrom.Workflow, Namespace: cr.Namespace}, earlier)
+	if err != nil {
+		return "", err
+	}
+
+	instanceName := createdInstanceName(earlier)
+	if instanceName == "" {
+		return fmt.Sprintf("the instance directory of Workflow %s is unknown", earlier.Name), nil
+	}
+
+	restarted := cr.DeepCopy()
+	inheritSettings(restarted, earlier)
+
+	if !sameWorkingVolume(restarted, earlier) {
+		return fmt.Sprintf("Workflow %s uses a different working volume", earlier.Name), nil
+	}
+
+	cr.Spec = restarted.Spec
+
+	cr.Status.Restart = &st4sdv1alpha1.WorkflowRestart{InstanceName: instanceName, Stage: cr.Spec.RestartFrom.Stage}
+	return "", nil
+}
+
+// inheritSettings copies the settings that the orchestrator of a Workflow needs to restart the instance
+// directory of an earlier Workflow, unless the Workflow sets them
+func inheritSettings(cr *st4sdv1alpha1.Workflow, earlier *st4sdv1alpha1.Workflow) {
+	if cr.Spec.Image == "" {
+		cr.Spec.Image = earlier.Spec.Image
+	}
+	if cr.Spec.Command == "" {
+		cr.Spec.Command = earlier.Spec.Command
+	}
+	if cr.Spec.WorkingVolume.Name == "" {
+		cr.Spec.WorkingVolume = *earlier.Spec.WorkingVolume.DeepCopy()
+	}
+	if len(cr.Spec.ImagePullSecrets) == 0 {
+		cr.Spec.ImagePullSecrets = append([]string{}, earlier.Spec.ImagePullSecrets...)
+	}
+	if len(cr.Spec.Volumes) == 0 && len(cr.Spec.VolumeMounts) == 0 {
+		for _, v := range earlier.Spec.Volumes {
+			cr.Spec.Volumes = append(cr.Spec.Volumes, *v.DeepCopy())
+		}
+		for _, v := range earlier.Spec.VolumeMounts {
+			cr.Spec.VolumeMounts = append(cr.Spec.VolumeMounts, *v.DeepCopy())
+		}
+	}
+	if len(cr.Spec.Env) == 0 {
+		for _, v := range earlier.Spec.Env {
+			cr.Spec.Env = append(cr.Spec.Env, *v.DeepCopy())
+		}
+	}
+	if cr.Spec.Resources == nil && earlier.Spec.Resources != nil {
+		cr.Spec.Resources = earlier.Spec.Resources.DeepCopy()
+	}
+	if cr.Spec.OrchestratorOptions == nil && earlier.Spec.OrchestratorOptions != nil {
+		cr.Spec.OrchestratorOptions = earlier.Spec.OrchestratorOptions.DeepCopy()
+		cr.Spec.OrchestratorOptions.RestartFromStage = nil
+	}
+}
@@ -0,0 +1,75 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestRestartFrom tests that a Workflow with spec.restartFrom restarts the instance directory of the
+// earlier Workflow using its settings
+func TestRestartFrom(t *testing.T) {
+	earlier := newTestWorkflow("earlier")
+	earlier.Spec.Image = "quay.io/st4sd/official-base/st4sd-runtime-core:custom"
+	earlier.Spec.Env = []corev1.EnvVar{{Name: "HELLO", Value: "world"}}
+	earlier.Status.InstanceName = "earlier-abcdef"
+	earlier.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFailed
+
+	restart := newTestWorkflow("restart")
+	restart.Spec.Package = nil
+	restart.Spec.WorkingVolume = corev1.Volume{}
+	restart.Spec.RestartFrom = &st4sdv1alpha1.RestartFrom{Workflow: "earlier", Stage: 2}
+
+	r := newTestReconciler(earlier, restart)
+	reconcileTestWorkflow(t, r, restart.Name)
+
+	pod := getTestPod(r, restart.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod", "status", getTestWorkflow(t, r, restart.Name).Status)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if primary.Image != earlier.Spec.Image {
+		t.Error("Expected the image of the earlier Workflow", "image", primary.Image)
+	}
+	if !contains(primary.Command, "--restart=2") || !contains(primary.Command, "/tmp/workdir/earlier-abcdef") {
+		t.Error("Expected the orchestrator to restart the earlier instance", "command", primary.Command)
+	}
+
+	if updated := getTestWorkflow(t, r, restart.Name); len(updated.Spec.Env) != 1 ||
+		updated.Spec.WorkingVolume.Name != earlier.Spec.WorkingVolume.Name {
+		t.Error("Expected the settings of the earlier Workflow", "spec", updated.Spec)
+	}
+}
+
+// TestRestartFromUnknownInstance tests that a Workflow cannot restart a Workflow without an instance directory
+func TestRestartFromUnknownInstance(t *testing.T) {
+	earlier := newTestWorkflow("earlier")
+	earlier.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFailed
+
+	restart := newTestWorkflow("restart")
+	restart.Spec.Package = nil
+	restart.Spec.RestartFrom = &st4sdv1alpha1.RestartFrom{Workflow: "earlier"}
+
+	r := newTestReconciler(earlier, restart)
+	reconcileTestWorkflow(t, r, restart.Name)
+
+	if updated := getTestWorkflow(t, r, restart.Name); updated.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		updated.Status.Reason != st4sdv1alpha1.ReasonInvalidRestart {
+		t.Error("Expected the Workflow to fail", "status", updated.Status)
+	}
+	if getTestPod(r, restart.Name) != nil {
+		t.Error("Did not expect a pod")
+	}
+}
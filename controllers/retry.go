@@ -0,0 +1,158 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+const (
+	defaultRetryBackoff = 10 * time.Second
+	maxRetryBackoff     = time.Hour
+)
+
+// podFailure returns the infrastructure failure of a pod and a message describing it, or an empty
+// RetryReason if the pod did not fail because of the infrastructure
+func podFailure(pod *corev1.Pod) (st4sdv1alpha1.RetryReason, string) {
+	if pod.Status.Reason == "Evicted" {
+		return st4sdv1alpha1.RetryEvicted, pod.Status.Message
+	}
+
+	if pod.Status.Reason == "NodeLost" || pod.Status.Phase == corev1.PodUnknown {
+		return st4sdv1alpha1.RetryNodeLost, fmt.Sprintf("lost contact with node %s", pod.Spec.NodeName)
+	}
+	for _, v := range pod.Status.Conditions {
+		if v.Type == corev1.DisruptionTarget && v.Status == corev1.ConditionTrue &&
+			v.Reason == "DeletionByTaintManager" {
+			return st4sdv1alpha1.RetryNodeLost, v.Message
+		}
+	}
+
+	for _, v := range pod.Status.InitContainerStatuses {
+		if terminated := v.State.Terminated; terminated != nil {
+			if terminated.Reason == "OOMKilled" {
+				return st4sdv1alpha1.RetryOOMKilled, fmt.Sprintf("init-container %s ran out of memory", v.Name)
+			} else if terminated.ExitCode != 0 && contains(packageFetchContainers, v.Name) {
+				return st4sdv1alpha1.RetryPackageFetchFailed,
+					fmt.Sprintf("init-container %s exited with %d", v.Name, terminated.ExitCode)
+			}
+		}
+	}
+
+	for _, v := range pod.Status.ContainerStatuses {
+		if terminated := v.State.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+			return st4sdv1alpha1.RetryOOMKilled, fmt.Sprintf("container %s ran out of memory", v.Name)
+		}
+	}
+
+	return "", ""
+}
+
+// retryBackoff returns how long to wait before replacing the pod of a failed attempt
+func retryBackoff(policy *st4sdv1alpha1.RetryPolicy, attempt int) time.Duration {
+	backoff := defaultRetryBackoff
+	if policy.Backoff != nil {
+		backoff = policy.Backoff.Duration
+	}
+
+	for i := 0; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// reconcileRetry replaces the primary pod of a Workflow when it fails in a way that spec.retryPolicy
+// retries. It returns how long to wait before creating the replacement pod.
+func (r *WorkflowReconciler) reconcileRetry(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) (time.Duration, error) {
+	policy := instance.Spec.RetryPolicy
+
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	} else if err != nil {
+		// VV: Wait for the backoff of the last failed attempt before creating its replacement
+		if len(instance.Status.Attempts) == 0 {
+			return 0, nil
+		}
+		last := instance.Status.Attempts[len(instance.Status.Attempts)-1]
+		return time.Until(last.FinishedAt.Add(retryBackoff(policy, len(instance.Status.Attempts)-1))), nil
+	}
+
+	if int32(len(instance.Status.Attempts)) >= policy.Limit {
+		return 0, nil
+	}
+
+	reason, message := podFailure(pod)
+	if reason == "" || (len(policy.RetryOn) > 0 && !containsRetryReason(policy.RetryOn, reason)) {
+		return 0, nil
+	}
+
+	if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed &&
+		reason != st4sdv1alpha1.RetryNodeLost {
+		if err := r.stopPod(ctx, reqLogger, pod); err != nil {
+			return 0, err
+		}
+	}
+
+	instance.Status.Attempts = append(instance.Status.Attempts, st4sdv1alpha1.WorkflowAttempt{
+		Pod: pod.Name, Reason: reason, Message: message, FinishedAt: metav1.Now()})
+
+	// VV: Restart the instance directory of the failed attempt, if the orchestrator created one
+	instanceName := createdInstanceName(instance)
+	if instanceName != "" {
+		instance.Status.Restart = &st4sdv1alpha1.WorkflowRestart{
+			InstanceName: instanceName, Stage: currentStageIndex(&instance.Status)}
+	}
+
+	instance.Status.Updated = ""
+	instance.Status.Experimentstate = ""
+	instance.Status.Exitstatus = ""
+	instance.Status.Errordescription = ""
+	instance.Status.S3Fetch = nil
+	instance.Status.S3Upload = nil
+	instance.Status.CompletionTime = nil
+
+	backoff := retryBackoff(policy, len(instance.Status.Attempts)-1)
+	message = fmt.Sprintf("pod %s failed with %s (%s), creating %s in %s", pod.Name, reason, message,
+		primaryPodName(instance), backoff)
+
+	reqLogger.Info("Retrying Workflow", "reason", reason, "attempt", len(instance.Status.Attempts))
+	event := newWorkflowEvent(instance, "Warning", string(reason), message)
+	if err := r.Client.Create(ctx, event); err != nil {
+		reqLogger.Error(err, "Error in creating event")
+	}
+
+	return backoff, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowWaiting, st4sdv1alpha1.ReasonRetryBackoff, message)
+}
+
+func containsRetryReason(list []st4sdv1alpha1.RetryReason, reason st4sdv1alpha1.RetryReason) bool {
+	for _, v := range list {
+		if v == reason {
+			return true
+		}
+	}
+	return false
+}
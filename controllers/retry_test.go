@@ -0,0 +1,100 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestRetryPolicy tests that the operator replaces a pod which fails to fetch the package with
+// $name-attempt-1 after the backoff, and gives up after spec.retryPolicy.limit attempts
+func TestRetryPolicy(t *testing.T) {
+	wf := newTestWorkflow("retry")
+	wf.Spec.RetryPolicy = &st4sdv1alpha1.RetryPolicy{
+		Limit:   1,
+		Backoff: &metav1.Duration{Duration: time.Minute},
+		RetryOn: []st4sdv1alpha1.RetryReason{st4sdv1alpha1.RetryPackageFetchFailed},
+	}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	failPackageFetch := func(name string) {
+		pod := getTestPod(r, name)
+		if pod == nil {
+			t.Fatal("Expected pod", name)
+		}
+		pod.Status.Phase = corev1.PodFailed
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "git-sync-package",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		if err := r.Status().Update(ctx, pod); err != nil {
+			t.Fatal("Unable to update pod", err)
+		}
+	}
+
+	failPackageFetch(wf.Name)
+	if result := reconcileTestWorkflow(t, r, wf.Name); result.RequeueAfter != time.Minute {
+		t.Error("Expected to wait for the backoff", "result", result)
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if len(wf.Status.Attempts) != 1 || wf.Status.Attempts[0].Pod != "retry" ||
+		wf.Status.Attempts[0].Reason != st4sdv1alpha1.RetryPackageFetchFailed ||
+		wf.Status.Phase != st4sdv1alpha1.WorkflowWaiting {
+		t.Fatal("Unexpected status", "status", wf.Status)
+	}
+
+	// VV: Pretend that the backoff expired
+	wf.Status.Attempts[0].FinishedAt = metav1.NewTime(time.Now().Add(-time.Hour))
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if getTestPod(r, "retry-attempt-1") == nil {
+		t.Fatal("Expected the pod retry-attempt-1")
+	}
+
+	failPackageFetch("retry-attempt-1")
+	reconcileTestWorkflow(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if wf = getTestWorkflow(t, r, wf.Name); len(wf.Status.Attempts) != 1 || wf.Status.Phase != st4sdv1alpha1.WorkflowFailed {
+		t.Error("Expected the Workflow to fail after the retry limit", "status", wf.Status)
+	}
+}
+
+// TestPodFailure tests the classification of infrastructure failures
+func TestPodFailure(t *testing.T) {
+	evicted := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}}
+	oomKilled := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "elaunch-primary", State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}}}}}}
+	failed := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "elaunch-primary", State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}}}}}
+
+	for pod, expected := range map[*corev1.Pod]st4sdv1alpha1.RetryReason{
+		evicted: st4sdv1alpha1.RetryEvicted, oomKilled: st4sdv1alpha1.RetryOOMKilled, failed: ""} {
+		if reason, _ := podFailure(pod); reason != expected {
+			t.Error("Unexpected failure", "actual", reason, "expected", expected)
+		}
+	}
+}
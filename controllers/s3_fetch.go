@@ -0,0 +1,206 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// s3FetchObjectsScript downloads the objects of the S3 bucket in the S3_* environment variables that
+// match the JSON list of selectors in the S3_FETCH_SELECTORS environment variable. Its arguments are:
+// $rootDir $maxTotalBytes. A $maxTotalBytes of 0 means that there is no limit. It reports the fetched objects in the termination
+// message as a JSON dictionary with the schema of S3FetchStatus.
+const s3FetchObjectsScript = `
+import fnmatch
+import hashlib
+import json
+import os
+import sys
+
+import boto3
+
+bucket = os.environ["S3_BUCKET"]
+client = boto3.client(
+    "s3",
+    endpoint_url=os.environ.get("S3_ENDPOINT") or None,
+    region_name=os.environ.get("S3_REGION") or None,
+    aws_access_key_id=os.environ.get("S3_ACCESS_KEY_ID") or None,
+    aws_secret_access_key=os.environ.get("S3_SECRET_ACCESS_KEY") or None,
+)
+
+root_dir = sys.argv[1]
+max_total_bytes = int(sys.argv[2])
+selectors = json.loads(os.environ["S3_FETCH_SELECTORS"])
+report = {"objects": [], "totalObjects": 0, "totalBytes": 0}
+
+
+def finish(error=None):
+    if error:
+        report["error"] = error
+        print(error, file=sys.stderr)
+    message = json.dumps(report)
+    while len(message) > 4000 and report["objects"]:
+        # VV: The termination message cannot be larger than 4KB, keep just the first objects
+        report["objects"] = report["objects"][: len(report["objects"]) // 2]
+        report["truncated"] = True
+        message = json.dumps(report)
+    with open("/dev/termination-log", "w") as f:
+        f.write(message)
+    sys.exit(1 if error else 0)
+
+
+def checksum(path, algorithm):
+    h = algorithm()
+    with open(path, "rb") as f:
+        for chunk in iter(lambda: f.read(1024 * 1024), b""):
+            h.update(chunk)
+    return h.hexdigest()
+
+
+planned = []
+paginator = client.get_paginator("list_objects_v2")
+for selector in selectors:
+    matched = []
+    for page in paginator.paginate(Bucket=bucket, Prefix=selector.get("prefix", "")):
+        for obj in page.get("Contents", []):
+            if obj["Key"].endswith("/"):
+                continue
+            if selector.get("glob") and not fnmatch.fnmatchcase(obj["Key"], selector["glob"]):
+                continue
+            matched.append(obj)
+    if not matched:
+        finish("No objects match %s" % json.dumps(selector))
+    if selector.get("sha256") and len(matched) != 1:
+        finish("Expected exactly 1 object to match %s but found %d" % (json.dumps(selector), len(matched)))
+    planned.extend((selector, obj) for obj in matched)
+
+total_bytes = sum(obj["Size"] for _, obj in planned)
+if max_total_bytes and total_bytes > max_total_bytes:
+    finish("Objects are %d bytes which is more than the limit of %d bytes" % (total_bytes, max_total_bytes))
+
+for selector, obj in planned:
+    path = os.path.join(root_dir, selector.get("folder") or "input", obj["Key"])
+    os.makedirs(os.path.dirname(path), exist_ok=True)
+    print("Fetching", obj["Key"], "to", path)
+    client.download_file(bucket, obj["Key"], path)
+
+    verified = ""
+    if selector.get("sha256"):
+        if checksum(path, hashlib.sha256) != selector["sha256"].lower():
+            finish("The sha256 checksum of %s does not match %s" % (obj["Key"], selector["sha256"]))
+        verified = "sha256"
+    elif selector.get("verifyETag"):
+        etag = obj.get("ETag", "").strip('"')
+        if "-" not in etag:
+            if checksum(path, hashlib.md5) != etag:
+                finish("The MD5 checksum of %s does not match its ETag %s" % (obj["Key"], etag))
+            verified = "etag"
+
+    report["objects"].append({"key": obj["Key"], "size": obj["Size"], "verified": verified})
+    report["totalObjects"] += 1
+    report["totalBytes"] += obj["Size"]
+
+finish()
+`
+
+// newS3FetchObjectsContainer returns the init-container which fetches spec.s3BucketInput.objects
+// under rootDir using the s3-fetch image. The container expects that volumeMounts include the volume that
+// rootDir is on. The selectors reach the script via an environment variable and never go through a shell.
+func newS3FetchObjectsContainer(cr *st4sdv1alpha1.Workflow, image string, rootDir string,
+	volumeMounts []corev1.VolumeMount, resources corev1.ResourceRequirements,
+	securityContext *corev1.SecurityContext) (corev1.Container, error) {
+	selectors, err := json.Marshal(cr.Spec.S3BucketInput.Objects)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+
+	maxTotalBytes := int64(0)
+	if cr.Spec.S3BucketInput.MaxTotalSize != nil {
+		maxTotalBytes = cr.Spec.S3BucketInput.MaxTotalSize.Value()
+	}
+
+	env := []corev1.EnvVar{{Name: "S3_FETCH_SELECTORS", Value: string(selectors)}}
+	env = append(env, s3BucketEnvVars(&cr.Spec.S3BucketInput.DatashimS3BucketInfo)...)
+
+	return corev1.Container{
+		Name:                     "s3-fetch-objects",
+		Image:                    image,
+		Command:                  []string{"python3", "-c", s3FetchObjectsScript},
+		Args:                     []string{rootDir, strconv.FormatInt(maxTotalBytes, 10)},
+		Env:                      env,
+		ImagePullPolicy:          corev1.PullAlways,
+		VolumeMounts:             volumeMounts,
+		Resources:                resources,
+		WorkingDir:               "/workdir",
+		SecurityContext:          securityContext,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}, nil
+}
+
+// terminatedInitContainer returns the terminated state of an init-container in a pod, or nil if the
+// init-container has not terminated yet
+func terminatedInitContainer(pod *corev1.Pod, name string) *corev1.ContainerStateTerminated {
+	for _, v := range pod.Status.InitContainerStatuses {
+		if v.Name == name {
+			return v.State.Terminated
+		}
+	}
+	return nil
+}
+
+// reconcileS3Fetch records the report of the s3-fetch-objects init-container in status.s3Fetch
+func (r *WorkflowReconciler) reconcileS3Fetch(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	if instance.Status.S3Fetch != nil {
+		return nil
+	}
+
+	primary := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, primary)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	fetch := terminatedInitContainer(primary, "s3-fetch-objects")
+	if fetch == nil {
+		return nil
+	}
+
+	report := st4sdv1alpha1.S3FetchStatus{}
+	if err := json.Unmarshal([]byte(fetch.Message), &report); err != nil {
+		report.Error = fmt.Sprintf("unable to decode the report of s3-fetch-objects %s: %v", fetch.Message, err)
+	} else if fetch.ExitCode != 0 && report.Error == "" {
+		report.Error = fmt.Sprintf("s3-fetch-objects exited with %d", fetch.ExitCode)
+	}
+
+	instance.Status.S3Fetch = &report
+
+	if report.Error != "" {
+		event := newWorkflowEvent(instance, "Warning", "S3FetchFailed", report.Error)
+		if err := r.Client.Create(ctx, event); err != nil {
+			reqLogger.Error(err, "Error in creating event")
+		}
+	}
+
+	return r.Client.Update(ctx, instance)
+}
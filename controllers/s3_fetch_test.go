@@ -0,0 +1,116 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestNewPodForCRS3FetchObjects tests that spec.s3BucketInput.objects produces the s3-fetch-objects init-container
+func TestNewPodForCRS3FetchObjects(t *testing.T) {
+	wf := newTestWorkflow("s3-fetch-objects")
+	wf.Spec.S3BucketInput = &st4sdv1alpha1.S3BucketInputInfo{
+		DatashimS3BucketInfo: st4sdv1alpha1.DatashimS3BucketInfo{Dataset: "my-dataset"},
+		Objects: []st4sdv1alpha1.S3ObjectSelector{
+			{Prefix: "molecules/$(reboot); '", Glob: "*.csv", VerifyETag: true},
+		},
+	}
+	wf.Spec.S3FetchFilesImage = "example.com/s3-fetch"
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	if len(pod.Spec.InitContainers) < 2 {
+		t.Fatal("Expected s3-fetch and s3-fetch-objects init-containers")
+	}
+
+	fetch := pod.Spec.InitContainers[len(pod.Spec.InitContainers)-1]
+	if fetch.Name != "s3-fetch-objects" {
+		t.Fatal("Expected s3-fetch-objects to be the last init-container, got", fetch.Name)
+	}
+
+	if fetch.Image != "example.com/s3-fetch" {
+		t.Error("Expected the s3-fetch image", "image", fetch.Image)
+	}
+
+	expected := []string{"/tmp/s3-root-dir", "0"}
+	if len(fetch.Command) != 3 || len(fetch.Args) != len(expected) {
+		t.Fatal("Unexpected command", fetch.Command, "args", fetch.Args)
+	}
+	for i, v := range expected {
+		if fetch.Args[i] != v {
+			t.Error("Unexpected argument", "actual", fetch.Args[i], "expected", v)
+		}
+	}
+
+	// VV: The selectors reach the script verbatim via the environment, not via a shell
+	selectors := `[{"prefix":"molecules/$(reboot); '","glob":"*.csv","verifyETag":true}]`
+	found := false
+	for _, v := range fetch.Env {
+		if v.Name == "S3_FETCH_SELECTORS" {
+			found = true
+			if v.Value != selectors {
+				t.Error("Unexpected selectors", "actual", v.Value, "expected", selectors)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the S3_FETCH_SELECTORS env variable", "env", fetch.Env)
+	}
+}
+
+// TestReconcileS3Fetch tests that the report of s3-fetch-objects ends up in status.s3Fetch
+func TestReconcileS3Fetch(t *testing.T) {
+	wf := newTestWorkflow("s3-fetch-report")
+	wf.Spec.S3BucketInput = &st4sdv1alpha1.S3BucketInputInfo{
+		Objects: []st4sdv1alpha1.S3ObjectSelector{{Prefix: "molecules/"}},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: wf.Name, Namespace: wf.Namespace},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name: "s3-fetch-objects",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message: `{"objects": [{"key": "molecules/a.csv", "size": 10, "verified": "etag"}], ` +
+						`"totalObjects": 1, "totalBytes": 10}`,
+				}},
+			}},
+		},
+	}
+
+	r := newTestReconciler(wf, pod)
+	ctx := context.TODO()
+	if err := r.reconcileS3Fetch(ctx, log.FromContext(ctx), wf); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	updated := &st4sdv1alpha1.Workflow{}
+	if err := r.Get(ctx, types.NamespacedName{Name: wf.Name, Namespace: wf.Namespace}, updated); err != nil {
+		t.Fatal("Unable to get workflow", err)
+	}
+
+	report := updated.Status.S3Fetch
+	if report == nil || report.TotalObjects != 1 || report.TotalBytes != 10 || len(report.Objects) != 1 ||
+		report.Objects[0].Key != "molecules/a.csv" || report.Objects[0].Verified != "etag" || report.Error != "" {
+		t.Error("Unexpected report", report)
+	}
+}
@@ -0,0 +1,336 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// s3UploadFilesScript uploads files to the S3 bucket in the S3_* environment variables.
+// Its arguments are: $prefix $instanceDir followed by pairs of $label $pattern. Patterns are globs,
+// relative paths are relative to $instanceDir. It reports the URIs of the objects it creates in the
+// termination message as a JSON dictionary {$label: [$uri]}.
+const s3UploadFilesScript = `
+import glob
+import json
+import os
+import sys
+
+import boto3
+
+bucket = os.environ["S3_BUCKET"]
+client = boto3.client(
+    "s3",
+    endpoint_url=os.environ.get("S3_ENDPOINT") or None,
+    region_name=os.environ.get("S3_REGION") or None,
+    aws_access_key_id=os.environ.get("S3_ACCESS_KEY_ID") or None,
+    aws_secret_access_key=os.environ.get("S3_SECRET_ACCESS_KEY") or None,
+)
+
+prefix = sys.argv[1].strip("/")
+instance_dir = sys.argv[2]
+args = sys.argv[3:]
+
+uploaded = {}
+for label, pattern in zip(args[0::2], args[1::2]):
+    if not os.path.isabs(pattern):
+        pattern = os.path.join(instance_dir, pattern)
+    uris = []
+    for match in sorted(glob.glob(pattern, recursive=True)):
+        if os.path.isdir(match):
+            files = [os.path.join(root, name) for root, _, names in os.walk(match) for name in names]
+        else:
+            files = [match]
+        for file in files:
+            if file.startswith(instance_dir.rstrip("/") + "/"):
+                rel_path = os.path.relpath(file, instance_dir)
+            else:
+                rel_path = os.path.basename(file)
+            key = "/".join(x for x in (prefix, rel_path) if x)
+            print("Uploading", file, "to", key)
+            client.upload_file(file, bucket, key)
+            uris.append("s3://%s/%s" % (bucket, key))
+    uploaded[label] = uris
+
+message = json.dumps(uploaded)
+if len(message) > 4000:
+    # VV: The termination message cannot be larger than 4KB, just report the prefix
+    message = json.dumps({label: ["s3://%s/%s/" % (bucket, prefix)] for label in uploaded})
+
+with open("/dev/termination-log", "w") as f:
+    f.write(message)
+`
+
+// s3UploadPodName returns the name of the pod which uploads the outputs of a Workflow to S3
+func s3UploadPodName(cr *st4sdv1alpha1.Workflow) string {
+	return primaryPodName(cr) + "-s3-upload"
+}
+
+// instanceDirectory returns the path to the instance directory of the Workflow in the primary pod and
+// an empty string if it is unknown
+func instanceDirectory(cr *st4sdv1alpha1.Workflow) string {
+	if cr.Status.InstanceName != "" {
+		return path.Join("/tmp/workdir", cr.Status.InstanceName)
+	}
+	return ""
+}
+
+// s3UploadPatterns returns pairs of ($label, $pattern) for the spec.s3BucketOutput.outputs entries.
+// Names of key-outputs resolve to their filepath, other entries are glob patterns
+func s3UploadPatterns(cr *st4sdv1alpha1.Workflow) ([]string, error) {
+	instanceDir := instanceDirectory(cr)
+	ret := []string{}
+
+	for _, v := range cr.Spec.S3BucketOutput.Outputs {
+		pattern := v
+		if keyOutput, ok := cr.Status.Outputfiles[v]; ok && keyOutput["filepath"] != "" {
+			pattern = keyOutput["filepath"]
+		}
+
+		if instanceDir == "" && !path.IsAbs(pattern) {
+			return nil, fmt.Errorf("cannot upload %s because the instance directory of the workflow is unknown", v)
+		}
+		ret = append(ret, v, pattern)
+	}
+
+	return ret, nil
+}
+
+// newS3UploadPod returns a pod which uploads the outputs of the Workflow to spec.s3BucketOutput. The pod
+// has the scheduling constraints and image pull secrets of the primary pod, and the resources of s3Fetch
+func newS3UploadPod(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod, error) {
+	patterns, err := s3UploadPatterns(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	options := getDefaultValues(r, cr.Namespace, configMapName())
+
+	image := cr.Spec.S3UploadFilesImage
+	if image == "" {
+		image = options.S3UploadFilesImage
+	}
+	if image == "" {
+		// VV: The orchestrator image contains python and boto3
+		image = cr.Spec.Image
+	}
+
+	prefix := cr.Spec.S3BucketOutput.Prefix
+	if prefix == "" {
+		prefix = cr.Name
+	}
+
+	resources, err := containerResources(cr, options)
+	if err != nil {
+		return nil, err
+	}
+	uploadResources, err := resourceRequirements(resources.S3Fetch)
+	if err != nil {
+		return nil, newResourcesError("s3Fetch: %v", err)
+	}
+
+	pullSecrets := cr.Spec.ImagePullSecrets
+	if len(pullSecrets) == 0 {
+		pullSecrets = options.ImagePullSecrets
+	}
+	imagePullSecrets := []corev1.LocalObjectReference{}
+	for _, v := range pullSecrets {
+		imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{Name: v})
+	}
+
+	container := corev1.Container{
+		Name:            "s3-upload",
+		Image:           image,
+		Command:         append([]string{"python3", "-c", s3UploadFilesScript, prefix, instanceDirectory(cr)}, patterns...),
+		Env:             s3BucketEnvVars(&cr.Spec.S3BucketOutput.DatashimS3BucketInfo),
+		ImagePullPolicy: corev1.PullAlways,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      cr.Spec.WorkingVolume.Name,
+				MountPath: "/tmp/workdir",
+				ReadOnly:  true,
+			},
+		},
+		Resources:  uploadResources,
+		WorkingDir: "/tmp/workdir",
+	}
+
+	// VV: Do not store the scheduling defaults in the spec of the Workflow
+	scheduling := cr.DeepCopy()
+	applySchedulingDefaults(scheduling, options)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s3UploadPodName(cr),
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"workflow": cr.Name,
+				"rest-uid": fmt.Sprint(cr.UID),
+			},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName:        serviceAccountName(),
+			RestartPolicy:             corev1.RestartPolicyNever,
+			Volumes:                   []corev1.Volume{cr.Spec.WorkingVolume},
+			Containers:                []corev1.Container{container},
+			SecurityContext:           podSecurityContext(options),
+			NodeSelector:              scheduling.Spec.NodeSelector,
+			Tolerations:               scheduling.Spec.Tolerations,
+			Affinity:                  scheduling.Spec.Affinity,
+			TopologySpreadConstraints: scheduling.Spec.TopologySpreadConstraints,
+			PriorityClassName:         scheduling.Spec.PriorityClassName,
+			RuntimeClassName:          scheduling.Spec.RuntimeClassName,
+		},
+	}
+
+	if len(imagePullSecrets) != 0 {
+		pod.Spec.ImagePullSecrets = imagePullSecrets
+	}
+
+	return pod, nil
+}
+
+// terminatedContainer returns the terminated state of a container in a pod, or nil if the
+// container has not terminated yet
+func terminatedContainer(pod *corev1.Pod, name string) *corev1.ContainerStateTerminated {
+	for _, v := range pod.Status.ContainerStatuses {
+		if v.Name == name {
+			return v.State.Terminated
+		}
+	}
+	return nil
+}
+
+// recordS3Upload stores the URIs that the s3-upload pod reported in its termination message under
+// status.outputfiles[$label]["s3Uris"] as a comma separated list
+func recordS3Upload(cr *st4sdv1alpha1.Workflow, message string) error {
+	uploaded := map[string][]string{}
+	if err := json.Unmarshal([]byte(message), &uploaded); err != nil {
+		return fmt.Errorf("unable to decode the uploaded files %s: %v", message, err)
+	}
+
+	if cr.Status.Outputfiles == nil {
+		cr.Status.Outputfiles = map[string]map[string]string{}
+	}
+
+	for label, uris := range uploaded {
+		if cr.Status.Outputfiles[label] == nil {
+			cr.Status.Outputfiles[label] = map[string]string{}
+		}
+		cr.Status.Outputfiles[label]["s3Uris"] = strings.Join(uris, ",")
+	}
+
+	return nil
+}
+
+// reconcileS3Upload uploads the outputs of a Workflow to spec.s3BucketOutput after its orchestrator
+// terminates successfully and the monitoring side-container has reported the outputs, and records the
+// outcome in status.s3Upload
+func (r *WorkflowReconciler) reconcileS3Upload(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	if instance.Status.S3Upload != nil && instance.Status.S3Upload.State != st4sdv1alpha1.S3UploadRunning {
+		return nil
+	}
+
+	primary := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, primary)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	orchestrator := terminatedContainer(primary, "elaunch-primary")
+	if orchestrator == nil {
+		return nil
+	}
+
+	// VV: The monitoring side-container reports the key-outputs after the orchestrator terminates
+	if terminatedContainer(primary, "monitor-elaunch-container") == nil && !instance.Status.Completed() {
+		return nil
+	}
+
+	if orchestrator.ExitCode != 0 {
+		instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{
+			State:   st4sdv1alpha1.S3UploadSkipped,
+			Message: fmt.Sprintf("orchestrator exited with %d", orchestrator.ExitCode),
+		}
+		return r.Client.Update(ctx, instance)
+	}
+
+	upload := &corev1.Pod{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: s3UploadPodName(instance), Namespace: instance.Namespace}, upload)
+	if err != nil && errors.IsNotFound(err) {
+		upload, err = newS3UploadPod(r, instance)
+		if err != nil {
+			instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{
+				State: st4sdv1alpha1.S3UploadFailed, Message: err.Error()}
+			return r.Client.Update(ctx, instance)
+		}
+
+		if err := controllerutil.SetControllerReference(instance, upload, r.Scheme); err != nil {
+			return err
+		}
+
+		reqLogger.Info("Creating S3 upload Pod", "Pod.Namespace", upload.Namespace, "Pod.Name", upload.Name)
+		if err := r.Client.Create(ctx, upload); err != nil {
+			return err
+		}
+
+		instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{State: st4sdv1alpha1.S3UploadRunning}
+		return r.Client.Update(ctx, instance)
+	} else if err != nil {
+		return err
+	}
+
+	uploader := terminatedContainer(upload, "s3-upload")
+	if uploader == nil {
+		return nil
+	}
+
+	if uploader.ExitCode != 0 {
+		instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{
+			State:   st4sdv1alpha1.S3UploadFailed,
+			Message: fmt.Sprintf("s3-upload exited with %d, see the logs of pod %s", uploader.ExitCode, upload.Name),
+		}
+	} else if err := recordS3Upload(instance, uploader.Message); err != nil {
+		instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{
+			State: st4sdv1alpha1.S3UploadFailed, Message: err.Error()}
+	} else {
+		instance.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{State: st4sdv1alpha1.S3UploadSucceeded}
+	}
+
+	eventType := "Normal"
+	if instance.Status.S3Upload.State != st4sdv1alpha1.S3UploadSucceeded {
+		eventType = "Warning"
+	}
+	event := newWorkflowEvent(instance, eventType, "S3Upload"+string(instance.Status.S3Upload.State),
+		"Uploading outputs to S3 "+strings.ToLower(string(instance.Status.S3Upload.State))+
+			" "+instance.Status.S3Upload.Message)
+	if err := r.Client.Create(ctx, event); err != nil {
+		reqLogger.Error(err, "Error in creating event")
+	}
+
+	return r.Client.Update(ctx, instance)
+}
@@ -0,0 +1,233 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestS3UploadPatterns tests that key-outputs resolve to their filepath and other outputs are globs
+func TestS3UploadPatterns(t *testing.T) {
+	wf := newTestWorkflow("s3-upload")
+	wf.Spec.S3BucketOutput = &st4sdv1alpha1.S3BucketOutputInfo{
+		Outputs: []string{"OptimisationResults", "stages/stage1/*/out.csv"},
+	}
+	wf.Status.Outputfiles = map[string]map[string]string{
+		"OptimisationResults": {"filepath": "output/results.csv"},
+	}
+
+	if _, err := s3UploadPatterns(wf); err == nil {
+		t.Error("Expected an error because the instance directory is unknown")
+	}
+
+	wf.Status.InstanceName = "s3-upload-123.instance"
+	patterns, err := s3UploadPatterns(wf)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	expected := []string{"OptimisationResults", "output/results.csv",
+		"stages/stage1/*/out.csv", "stages/stage1/*/out.csv"}
+	if len(patterns) != len(expected) {
+		t.Fatal("Invalid patterns", "actual", patterns, "expected", expected)
+	}
+	for i := range expected {
+		if patterns[i] != expected[i] {
+			t.Error("Invalid patterns", "actual", patterns, "expected", expected)
+		}
+	}
+}
+
+// TestRecordS3Upload tests that the URIs in the termination message of s3-upload end up in status.outputfiles
+func TestRecordS3Upload(t *testing.T) {
+	wf := newTestWorkflow("s3-upload")
+	wf.Status.Outputfiles = map[string]map[string]string{
+		"OptimisationResults": {"filepath": "output/results.csv"},
+	}
+
+	err := recordS3Upload(wf, `{"OptimisationResults": ["s3://bucket/s3-upload/output/results.csv"],
+		"*.csv": ["s3://bucket/s3-upload/a.csv", "s3://bucket/s3-upload/b.csv"]}`)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if wf.Status.Outputfiles["OptimisationResults"]["filepath"] != "output/results.csv" ||
+		wf.Status.Outputfiles["OptimisationResults"]["s3Uris"] != "s3://bucket/s3-upload/output/results.csv" {
+		t.Error("Invalid key-output", wf.Status.Outputfiles["OptimisationResults"])
+	}
+
+	if wf.Status.Outputfiles["*.csv"]["s3Uris"] != "s3://bucket/s3-upload/a.csv,s3://bucket/s3-upload/b.csv" {
+		t.Error("Invalid glob output", wf.Status.Outputfiles["*.csv"])
+	}
+
+	if err := recordS3Upload(wf, "not json"); err == nil {
+		t.Error("Expected an error for an invalid termination message")
+	}
+}
+
+// TestS3UploadInstanceDirectory tests that the operator picks the instance directory of a new Workflow,
+// records it in status.instanceName, and uploads the outputs from it
+func TestS3UploadInstanceDirectory(t *testing.T) {
+	wf := newTestWorkflow("s3-upload")
+	wf.Spec.S3BucketOutput = &st4sdv1alpha1.S3BucketOutputInfo{Outputs: []string{"OptimisationResults"}}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.InstanceName != "s3-upload.instance" {
+		t.Fatal("Unexpected status.instanceName", wf.Status.InstanceName)
+	}
+
+	pod := getTestPod(r, wf.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod")
+	}
+	found := false
+	for _, v := range findContainer(pod.Spec.Containers, "elaunch-primary").Env {
+		if v.Name == "INSTANCE_DIR_NAME" {
+			found = true
+			if v.Value != wf.Status.InstanceName {
+				t.Error("Unexpected INSTANCE_DIR_NAME", v.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the INSTANCE_DIR_NAME env variable")
+	}
+
+	wf.Status.Updated = "now"
+	wf.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	wf.Status.Exitstatus = st4sdv1alpha1.ExitStatusSuccess
+	wf.Status.Outputfiles = map[string]map[string]string{
+		"OptimisationResults": {"filepath": "output/results.csv"},
+	}
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	pod.Status.Phase = corev1.PodSucceeded
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "elaunch-primary", State: terminated},
+		{Name: "monitor-elaunch-container", State: terminated},
+	}
+	if err := r.Status().Update(ctx, pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.S3Upload == nil || wf.Status.S3Upload.State != st4sdv1alpha1.S3UploadRunning {
+		t.Fatal("Expected the upload to be running", "s3Upload", wf.Status.S3Upload)
+	}
+
+	upload := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: s3UploadPodName(wf), Namespace: wf.Namespace}, upload); err != nil {
+		t.Fatal("Expected the s3-upload pod", err)
+	}
+	command := upload.Spec.Containers[0].Command
+	if command[4] != path.Join("/tmp/workdir", "s3-upload.instance") {
+		t.Error("Unexpected instance directory", "command", command)
+	}
+}
+
+// TestS3UploadWaitsForMonitor tests that the upload starts after the monitoring side-container terminates
+func TestS3UploadWaitsForMonitor(t *testing.T) {
+	wf := newTestWorkflow("s3-upload")
+	wf.Spec.S3BucketOutput = &st4sdv1alpha1.S3BucketOutputInfo{Outputs: []string{"*.csv"}}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	pod := getTestPod(r, wf.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod")
+	}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "elaunch-primary", State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+		{Name: "monitor-elaunch-container", State: corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{}}},
+	}
+	if err := r.Status().Update(ctx, pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.S3Upload != nil {
+		t.Fatal("Did not expect the upload to start before the monitor terminates", "s3Upload", wf.Status.S3Upload)
+	}
+
+	pod.Status.ContainerStatuses[1].State = corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	if err := r.Status().Update(ctx, pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.S3Upload == nil ||
+		wf.Status.S3Upload.State != st4sdv1alpha1.S3UploadRunning {
+		t.Error("Expected the upload to be running", "s3Upload", wf.Status.S3Upload)
+	}
+}
+
+// TestS3UploadPodScheduling tests that the s3-upload pod has the scheduling constraints, image pull secrets,
+// and s3Fetch resources of its Workflow and the defaults of the namespace
+func TestS3UploadPodScheduling(t *testing.T) {
+	runtimeClassName := "kata"
+	defaults := &st4sdv1alpha1.WorkflowDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: st4sdv1alpha1.WorkflowDefaultsSpec{
+			NodeSelector:     map[string]string{"pool": "compute"},
+			RuntimeClassName: &runtimeClassName,
+			ImagePullSecrets: []string{"registry"},
+			ResourceProfiles: map[string]st4sdv1alpha1.Resourcespec{
+				defaultResourceProfile: {S3Fetch: &st4sdv1alpha1.Resourcedefinition{Cpu: "250m", Memory: "1Gi"}},
+			},
+		},
+	}
+	r := newTestReconciler(defaults)
+
+	wf := newTestWorkflow("s3-upload-scheduling")
+	wf.Spec.S3BucketOutput = &st4sdv1alpha1.S3BucketOutputInfo{Outputs: []string{"*.csv"}}
+	wf.Spec.Tolerations = []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}}
+	wf.Status.InstanceName = "s3-upload-scheduling.instance"
+
+	pod, err := newS3UploadPod(r, wf)
+	if err != nil {
+		t.Fatal("Unable to generate the s3-upload pod", err)
+	}
+
+	if pod.Spec.NodeSelector["pool"] != "compute" || len(pod.Spec.Tolerations) != 1 ||
+		pod.Spec.RuntimeClassName == nil || *pod.Spec.RuntimeClassName != runtimeClassName {
+		t.Error("Unexpected scheduling constraints", "spec", pod.Spec)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "registry" {
+		t.Error("Unexpected imagePullSecrets", pod.Spec.ImagePullSecrets)
+	}
+	resources := pod.Spec.Containers[0].Resources
+	expectQuantity(t, "s3-upload limit", resources.Limits, corev1.ResourceCPU, "250m")
+	expectQuantity(t, "s3-upload request", resources.Requests, corev1.ResourceMemory, "1Gi")
+
+	if len(wf.Spec.NodeSelector) != 0 {
+		t.Error("Did not expect the defaults in the spec of the Workflow", "nodeSelector", wf.Spec.NodeSelector)
+	}
+}
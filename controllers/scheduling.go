@@ -0,0 +1,50 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// applySchedulingDefaults fills in the scheduling constraints of the primary pod that a Workflow does
+// not set with the defaults of its namespace. Each constraint defaults independently of the others.
+func applySchedulingDefaults(cr *st4sdv1alpha1.Workflow, options *st4sdv1alpha1.DefaultWorkflowOptions) {
+	if len(cr.Spec.NodeSelector) == 0 && len(options.NodeSelector) > 0 {
+		cr.Spec.NodeSelector = map[string]string{}
+		for key, value := range options.NodeSelector {
+			cr.Spec.NodeSelector[key] = value
+		}
+	}
+
+	if len(cr.Spec.Tolerations) == 0 && len(options.Tolerations) > 0 {
+		for _, toleration := range options.Tolerations {
+			cr.Spec.Tolerations = append(cr.Spec.Tolerations, *toleration.DeepCopy())
+		}
+	}
+
+	if cr.Spec.Affinity == nil && options.Affinity != nil {
+		cr.Spec.Affinity = options.Affinity.DeepCopy()
+	}
+
+	if len(cr.Spec.TopologySpreadConstraints) == 0 && len(options.TopologySpreadConstraints) > 0 {
+		for _, constraint := range options.TopologySpreadConstraints {
+			cr.Spec.TopologySpreadConstraints = append(cr.Spec.TopologySpreadConstraints, *constraint.DeepCopy())
+		}
+	}
+
+	if len(cr.Spec.PriorityClassName) == 0 {
+		cr.Spec.PriorityClassName = options.PriorityClassName
+	}
+
+	if cr.Spec.RuntimeClassName == nil && options.RuntimeClassName != nil {
+		runtimeClassName := *options.RuntimeClassName
+		cr.Spec.RuntimeClassName = &runtimeClassName
+	}
+}
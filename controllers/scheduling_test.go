@@ -0,0 +1,62 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSchedulingDefaults tests that the pod of a Workflow uses the scheduling constraints of the Workflow
+// and that the ones it does not set default to those in the config.json of the namespace
+func TestSchedulingDefaults(t *testing.T) {
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName(), Namespace: "default"},
+		Data: map[string]string{"config.json": `{
+			"nodeSelector": {"pool": "control-plane"},
+			"tolerations": [{"key": "dedicated", "operator": "Equal", "value": "st4sd", "effect": "NoSchedule"}],
+			"priorityClassName": "st4sd",
+			"runtimeClassName": "gvisor"
+		}`},
+	}
+
+	wf := newTestWorkflow("scheduling")
+	wf.Spec.NodeSelector = map[string]string{"pool": "orchestrators"}
+
+	r := newTestReconciler(wf, config)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	pod := getTestPod(r, wf.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod")
+	}
+	if len(pod.Spec.NodeSelector) != 1 || pod.Spec.NodeSelector["pool"] != "orchestrators" {
+		t.Error("Expected the nodeSelector of the Workflow", "nodeSelector", pod.Spec.NodeSelector)
+	}
+	if len(pod.Spec.Tolerations) != 1 || pod.Spec.Tolerations[0].Key != "dedicated" {
+		t.Error("Expected the default tolerations", "tolerations", pod.Spec.Tolerations)
+	}
+	if pod.Spec.PriorityClassName != "st4sd" {
+		t.Error("Expected the default priorityClassName", "priorityClassName", pod.Spec.PriorityClassName)
+	}
+	if pod.Spec.RuntimeClassName == nil || *pod.Spec.RuntimeClassName != "gvisor" {
+		t.Error("Expected the default runtimeClassName", "runtimeClassName", pod.Spec.RuntimeClassName)
+	}
+	if pod.Spec.Affinity != nil {
+		t.Error("Did not expect an affinity", "affinity", pod.Spec.Affinity)
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Spec.PriorityClassName != "st4sd" || len(wf.Spec.Tolerations) != 1 {
+		t.Error("Expected the Workflow to record the defaults", "spec", wf.Spec)
+	}
+}
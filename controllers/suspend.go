@@ -0,0 +1,119 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+var stageIndexPattern = regexp.MustCompile(`(\d+)$`)
+
+// currentStageIndex returns the index of the stage that the monitoring side-container last reported
+// for a Workflow, or 0 if it is unknown
+func currentStageIndex(status *st4sdv1alpha1.WorkflowStatus) int32 {
+	if status.Currentstage == "" {
+		return 0
+	}
+
+	for i, v := range status.Stages {
+		if v == status.Currentstage {
+			return int32(i)
+		}
+	}
+
+	if match := stageIndexPattern.FindString(status.Currentstage); match != "" {
+		if index, err := strconv.ParseInt(match, 10, 32); err == nil {
+			return int32(index)
+		}
+	}
+
+	return 0
+}
+
+// suspendWorkflow deletes the primary pod of a Workflow, records how to restart it in status.restart,
+// and moves the Workflow to the Suspended phase
+func (r *WorkflowReconciler) suspendWorkflow(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	message := "the workflow is suspended"
+
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	} else if err == nil && pod.GetDeletionTimestamp() == nil {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			// VV: The workflow finished, there is nothing to suspend
+			return r.syncPhase(ctx, instance)
+		}
+
+		instanceName := createdInstanceName(instance)
+
+		// VV: Without an instance directory the workflow starts from scratch when it resumes
+		if instanceName != "" {
+			instance.Status.Restart = &st4sdv1alpha1.WorkflowRestart{
+				InstanceName: instanceName,
+				Stage:        currentStageIndex(&instance.Status),
+			}
+			message = "the workflow is suspended and will restart from stage " +
+				strconv.Itoa(int(instance.Status.Restart.Stage)) + " of " + instanceName
+		}
+
+		reqLogger.Info("Suspending Pod", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+		// VV: The kubelet sends SIGTERM to the orchestrator and kills it after terminationGracePeriodSeconds
+		if err := r.Client.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		event := newWorkflowEvent(instance, "Normal", st4sdv1alpha1.ReasonSuspended, message)
+		if err := r.Client.Create(ctx, event); err != nil {
+			reqLogger.Error(err, "Error in creating event")
+		}
+	} else if instance.Status.Phase == st4sdv1alpha1.WorkflowSuspended {
+		message = instance.Status.Message
+	}
+
+	return r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowSuspended, st4sdv1alpha1.ReasonSuspended, message)
+}
+
+// resumeWorkflow clears the status that the monitoring side-container reported for the pod of a
+// suspended Workflow so that the operator creates a new pod. It returns false if the old pod still exists.
+func (r *WorkflowReconciler) resumeWorkflow(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) (bool, error) {
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(instance), Namespace: instance.Namespace}, pod)
+	if err == nil {
+		reqLogger.Info("Waiting for the Pod of the suspended Workflow to terminate", "Pod.Name", pod.Name)
+		return false, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	reqLogger.Info("Resuming Workflow", "restart", instance.Status.Restart)
+
+	instance.Status.Updated = ""
+	instance.Status.Experimentstate = ""
+	instance.Status.Exitstatus = ""
+	instance.Status.Errordescription = ""
+	instance.Status.Phase = ""
+	instance.Status.Reason = ""
+	instance.Status.Message = ""
+
+	return true, r.Client.Update(ctx, instance)
+}
@@ -0,0 +1,91 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestSuspendResume tests that suspending a running Workflow deletes its pod and that resuming it
+// restarts the orchestrator from the instance directory of the previous run
+func TestSuspendResume(t *testing.T) {
+	wf := newTestWorkflow("suspend")
+	wf.Spec.AdditionalOptions = []string{"--restart=0", "--failSafeDelays=no"}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) == nil {
+		t.Fatal("Expected a pod")
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	wf.Spec.Suspend = true
+	wf.Status.InstanceName = "suspend-abcdef"
+	wf.Status.Updated = "now"
+	wf.Status.Experimentstate = "running"
+	wf.Status.Stages = []string{"stage0", "stage1"}
+	wf.Status.Currentstage = "stage1"
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowSuspended {
+		t.Error("Expected the Workflow to be suspended", "status", wf.Status)
+	}
+	if wf.Status.Restart == nil || wf.Status.Restart.InstanceName != "suspend-abcdef" || wf.Status.Restart.Stage != 1 {
+		t.Fatal("Unexpected restart information", "restart", wf.Status.Restart)
+	}
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Did not expect a pod for the suspended Workflow")
+	}
+
+	wf.Spec.Suspend = false
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	pod := getTestPod(r, wf.Name)
+	if pod == nil {
+		t.Fatal("Expected a pod for the resumed Workflow", "status", getTestWorkflow(t, r, wf.Name).Status)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if !contains(primary.Command, "--restart=1") || contains(primary.Command, "--restart=0") ||
+		!contains(primary.Command, "/tmp/workdir/suspend-abcdef") {
+		t.Error("Expected the orchestrator to restart the instance", "command", primary.Command)
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowRunning {
+		t.Error("Expected the resumed Workflow to run", "status", wf.Status)
+	}
+}
+
+// TestSuspendBeforeStart tests that the operator does not create the pod of a suspended Workflow
+func TestSuspendBeforeStart(t *testing.T) {
+	wf := newTestWorkflow("suspend-before-start")
+	wf.Spec.Suspend = true
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Did not expect a pod for the suspended Workflow")
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowSuspended || wf.Status.Restart != nil {
+		t.Error("Expected the Workflow to be suspended without restart information", "status", wf.Status)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// packageFetchContainers are the names of the init-containers which fetch the workflow package
+var packageFetchContainers = []string{"git-sync-package", "s3-package-fetch"}
+
+// activeDeadlineSeconds converts a timeout to the activeDeadlineSeconds of a pod, rounding up to at least 1
+func activeDeadlineSeconds(timeout time.Duration) *int64 {
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &seconds
+}
+
+// enforcePackageFetchTimeout stops the primary pod of a Workflow and fails the Workflow with the reason
+// DeadlineExceeded if fetching the package takes longer than spec.packageFetchTimeout. The timeout is a
+// single budget which starts when the pod starts (or else when the first fetch init-container starts)
+// and includes the time that fetch init-containers spend waiting, e.g. in ImagePullBackOff. While the
+// package is being fetched, it returns how long until the timeout expires.
+func (r *WorkflowReconciler) enforcePackageFetchTimeout(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow, pod *corev1.Pod) (time.Duration, error) {
+	if instance.Spec.PackageFetchTimeout == nil || instance.Status.Completed() {
+		return 0, nil
+	}
+
+	fetching := false
+	started := pod.Status.StartTime
+	for _, v := range pod.Status.InitContainerStatuses {
+		if !contains(packageFetchContainers, v.Name) {
+			continue
+		}
+
+		var startedAt *metav1.Time
+		if v.State.Running != nil {
+			fetching = true
+			startedAt = &v.State.Running.StartedAt
+		} else if v.State.Waiting != nil {
+			fetching = true
+		} else if v.State.Terminated != nil {
+			startedAt = &v.State.Terminated.StartedAt
+		}
+
+		if pod.Status.StartTime == nil && startedAt != nil && (started == nil || startedAt.Before(started)) {
+			started = startedAt
+		}
+	}
+
+	if !fetching || started == nil {
+		return 0, nil
+	}
+
+	remaining := instance.Spec.PackageFetchTimeout.Duration - time.Since(started.Time)
+	if remaining > 0 {
+		return remaining, nil
+	}
+
+	if err := r.stopPod(ctx, reqLogger, pod); err != nil {
+		return 0, err
+	}
+
+	return 0, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed, st4sdv1alpha1.ReasonDeadlineExceeded,
+		"fetching the workflow package did not finish within spec.packageFetchTimeout "+
+			instance.Spec.PackageFetchTimeout.Duration.String())
+}
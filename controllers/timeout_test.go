@@ -0,0 +1,139 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// TestTimeout tests that spec.timeout becomes the activeDeadlineSeconds of the primary pod and that
+// the Workflow fails with the reason DeadlineExceeded when the pod exceeds it
+func TestTimeout(t *testing.T) {
+	wf := newTestWorkflow("timeout")
+	wf.Spec.Timeout = &metav1.Duration{Duration: 90*time.Minute + 500*time.Millisecond}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	pod := getTestPod(r, wf.Name)
+	if pod == nil || pod.Spec.ActiveDeadlineSeconds == nil || *pod.Spec.ActiveDeadlineSeconds != 5401 {
+		t.Fatal("Expected activeDeadlineSeconds to be 5401", "pod", pod)
+	}
+
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = "DeadlineExceeded"
+	if err := r.Status().Update(ctx, pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	wf.Status.Updated = "now"
+	wf.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFailed
+	if err := r.Update(ctx, wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonDeadlineExceeded {
+		t.Error("Expected the Workflow to fail with DeadlineExceeded", "status", wf.Status)
+	}
+}
+
+// TestPackageFetchTimeout tests that the operator stops a pod which fetches its package for longer
+// than spec.packageFetchTimeout
+func TestPackageFetchTimeout(t *testing.T) {
+	wf := newTestWorkflow("package-fetch-timeout")
+	wf.Spec.PackageFetchTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	pod := getTestPod(r, wf.Name)
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name: "git-sync-package",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{
+			StartedAt: metav1.NewTime(time.Now().Add(-5 * time.Minute))}},
+	}}
+
+	remaining, err := r.enforcePackageFetchTimeout(ctx, log.FromContext(ctx), wf, pod)
+	if err != nil || remaining <= 0 || remaining > 5*time.Minute {
+		t.Fatal("Expected the timeout to expire in about 5 minutes", "remaining", remaining, "err", err)
+	}
+
+	pod.Status.InitContainerStatuses[0].State.Running.StartedAt = metav1.NewTime(time.Now().Add(-time.Hour))
+	if _, err := r.enforcePackageFetchTimeout(ctx, log.FromContext(ctx), wf, pod); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if pod = getTestPod(r, wf.Name); pod.Spec.ActiveDeadlineSeconds == nil || *pod.Spec.ActiveDeadlineSeconds != 1 {
+		t.Error("Expected the pod to be stopped", "spec", pod.Spec)
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonDeadlineExceeded {
+		t.Error("Expected the Workflow to fail with DeadlineExceeded", "status", wf.Status)
+	}
+}
+
+// TestPackageFetchTimeoutWaiting tests that the package fetch timeout counts from the start of the pod and
+// includes fetch init-containers which are waiting e.g. in ImagePullBackOff
+func TestPackageFetchTimeoutWaiting(t *testing.T) {
+	wf := newTestWorkflow("package-fetch-waiting")
+	wf.Spec.PackageFetchTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+
+	r := newTestReconciler(wf)
+	ctx := context.TODO()
+	reconcileTestWorkflow(t, r, wf.Name)
+	wf = getTestWorkflow(t, r, wf.Name)
+	pod := getTestPod(r, wf.Name)
+
+	startTime := metav1.NewTime(time.Now().Add(-8 * time.Minute))
+	pod.Status.StartTime = &startTime
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "git-sync-package",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}}
+
+	remaining, err := r.enforcePackageFetchTimeout(ctx, log.FromContext(ctx), wf, pod)
+	if err != nil || remaining <= 0 || remaining > 2*time.Minute {
+		t.Fatal("Expected the timeout to expire in about 2 minutes", "remaining", remaining, "err", err)
+	}
+
+	// VV: Restarting the fetch init-container does not reset the budget
+	pod.Status.InitContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{
+		StartedAt: metav1.Now()}}
+	remaining, err = r.enforcePackageFetchTimeout(ctx, log.FromContext(ctx), wf, pod)
+	if err != nil || remaining <= 0 || remaining > 2*time.Minute {
+		t.Fatal("Expected the timeout to expire in about 2 minutes", "remaining", remaining, "err", err)
+	}
+
+	startTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	pod.Status.InitContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+		Reason: "ImagePullBackOff"}}
+	if _, err := r.enforcePackageFetchTimeout(ctx, log.FromContext(ctx), wf, pod); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonDeadlineExceeded {
+		t.Error("Expected the Workflow to fail with DeadlineExceeded", "status", wf.Status)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// reconcileTTL cleans up a finished Workflow after its ttlSecondsAfterFinished expires. Before that, it
+// returns how long until the TTL expires.
+func (r *WorkflowReconciler) reconcileTTL(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) (time.Duration, error) {
+	if !phaseIsFinal(instance.Status.Phase) {
+		return 0, nil
+	}
+
+	// VV: Wait for the outputs to reach S3 before deleting anything
+	if instance.Status.S3Upload != nil && instance.Status.S3Upload.State == st4sdv1alpha1.S3UploadRunning {
+		return 0, nil
+	} else if instance.Spec.S3BucketOutput != nil && instance.Status.S3Upload == nil && instance.Status.Succeeded() {
+		// VV: The upload starts after the monitoring side-container terminates
+		return 0, nil
+	}
+
+	ttlSeconds := instance.Spec.TTLSecondsAfterFinished
+	if ttlSeconds == nil {
+		ttlSeconds = getDefaultValues(r, instance.Namespace, configMapName()).TTLSecondsAfterFinished
+	}
+	if ttlSeconds == nil {
+		return 0, nil
+	}
+
+	if instance.Status.CompletionTime == nil {
+		// VV: The Workflow finished before the operator started recording the completion time
+		now := metav1.Now()
+		instance.Status.CompletionTime = &now
+		if err := r.Client.Update(ctx, instance); err != nil {
+			return 0, err
+		}
+	}
+
+	remaining := time.Until(instance.Status.CompletionTime.Add(time.Duration(*ttlSeconds) * time.Second))
+	if remaining > 0 {
+		return remaining, nil
+	}
+
+	if instance.Spec.TTLCleanup == st4sdv1alpha1.TTLCleanupPods {
+		return 0, r.deleteWorkflowPods(ctx, reqLogger, instance)
+	}
+
+	reqLogger.Info("Deleting finished Workflow", "ttlSecondsAfterFinished", *ttlSeconds)
+	return 0, client.IgnoreNotFound(r.Client.Delete(ctx, instance,
+		client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// deleteWorkflowPods deletes the pods that the operator created for a Workflow: the primary pods of all
+// its runs and attempts and its s3-upload pods. It finds them via their "workflow" label and deletes only
+// those that the Workflow controls, the pods that the orchestrator created belong to the primary pods.
+func (r *WorkflowReconciler) deleteWorkflowPods(ctx context.Context, reqLogger logr.Logger,
+	instance *st4sdv1alpha1.Workflow) error {
+	// VV: The primary pods use workflowLabel() and the s3-upload pods the name of the Workflow
+	selector, err := labels.NewRequirement("workflow", selection.In,
+		[]string{workflowLabel(instance), instance.Name})
+	if err != nil {
+		return err
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList, client.InNamespace(instance.Namespace),
+		client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*selector)}); err != nil {
+		return err
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !metav1.IsControlledBy(pod, instance) || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		err := r.Client.Delete(ctx, pod)
+		if err == nil {
+			reqLogger.Info("Deleted Pod of finished Workflow", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+		} else if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
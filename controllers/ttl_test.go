@@ -0,0 +1,150 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+// finishTestWorkflow marks a Workflow as succeeded at a point in the past
+func finishTestWorkflow(t *testing.T, r *WorkflowReconciler, name string, ago time.Duration) {
+	wf := getTestWorkflow(t, r, name)
+	wf.Status.Updated = "now"
+	wf.Status.Experimentstate = st4sdv1alpha1.ExperimentStateFinished
+	wf.Status.Exitstatus = st4sdv1alpha1.ExitStatusSuccess
+	wf.Status.Phase = st4sdv1alpha1.WorkflowSucceeded
+	completionTime := metav1.NewTime(time.Now().Add(-ago))
+	wf.Status.CompletionTime = &completionTime
+	if err := r.Update(context.TODO(), wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+}
+
+// succeedTestPod marks a pod as succeeded
+func succeedTestPod(t *testing.T, r *WorkflowReconciler, name string) {
+	pod := getTestPod(r, name)
+	if pod == nil {
+		t.Fatal("Expected pod", name)
+	}
+	pod.Status.Phase = corev1.PodSucceeded
+	if err := r.Status().Update(context.TODO(), pod); err != nil {
+		t.Fatal("Unable to update pod", err)
+	}
+}
+
+// TestTTLSecondsAfterFinished tests that the operator deletes finished Workflows after their TTL expires
+func TestTTLSecondsAfterFinished(t *testing.T) {
+	ttl := int32(3600)
+	wf := newTestWorkflow("ttl")
+	wf.Spec.TTLSecondsAfterFinished = &ttl
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	finishTestWorkflow(t, r, wf.Name, 30*time.Minute)
+	if result := reconcileTestWorkflow(t, r, wf.Name); result.RequeueAfter <= 0 || result.RequeueAfter > 30*time.Minute {
+		t.Error("Expected to requeue when the TTL expires", "result", result)
+	}
+
+	finishTestWorkflow(t, r, wf.Name, 2*time.Hour)
+	succeedTestPod(t, r, wf.Name)
+	reconcileTestWorkflow(t, r, wf.Name)
+	// VV: The finalizer releases the Workflow on the next reconcile
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	err := r.Get(context.TODO(), types.NamespacedName{Name: wf.Name, Namespace: wf.Namespace}, wf)
+	if err == nil {
+		t.Error("Expected the Workflow to be deleted")
+	}
+}
+
+// TestTTLCleanupPods tests that the operator can delete just the pods of finished Workflows
+func TestTTLCleanupPods(t *testing.T) {
+	ttl := int32(0)
+	wf := newTestWorkflow("ttl-pods")
+	wf.Spec.TTLSecondsAfterFinished = &ttl
+	wf.Spec.TTLCleanup = st4sdv1alpha1.TTLCleanupPods
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) == nil {
+		t.Fatal("Expected a pod")
+	}
+
+	// VV: A pod of a previous run and a pod of a different Workflow with the same name
+	wf = getTestWorkflow(t, r, wf.Name)
+	previous := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: wf.Name + "-run-1", Namespace: wf.Namespace,
+		Labels: map[string]string{"workflow": wf.Name}}}
+	if err := controllerutil.SetControllerReference(wf, previous, r.Scheme); err != nil {
+		t.Fatal("Unable to set owner", err)
+	}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: wf.Namespace,
+		Labels: map[string]string{"workflow": wf.Name, "rest-uid": "other-uid"}}}
+	for _, pod := range []*corev1.Pod{previous, other} {
+		if err := r.Create(context.TODO(), pod); err != nil {
+			t.Fatal("Unable to create pod", err)
+		}
+	}
+
+	finishTestWorkflow(t, r, wf.Name, time.Second)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Expected the pod to be deleted")
+	}
+	if getTestPod(r, previous.Name) != nil {
+		t.Error("Expected the pod of the previous run to be deleted")
+	}
+	if getTestPod(r, other.Name) == nil {
+		t.Error("Did not expect the pod of the other Workflow to be deleted")
+	}
+	if wf = getTestWorkflow(t, r, wf.Name); wf.Status.Phase != st4sdv1alpha1.WorkflowSucceeded {
+		t.Error("Expected the Workflow to remain", "status", wf.Status)
+	}
+}
+
+// TestTTLWaitsForS3Upload tests that the TTL of a succeeded Workflow does not expire before its upload to
+// S3 starts
+func TestTTLWaitsForS3Upload(t *testing.T) {
+	ttl := int32(0)
+	wf := newTestWorkflow("ttl-s3")
+	wf.Spec.TTLSecondsAfterFinished = &ttl
+	wf.Spec.TTLCleanup = st4sdv1alpha1.TTLCleanupPods
+	wf.Spec.S3BucketOutput = &st4sdv1alpha1.S3BucketOutputInfo{Outputs: []string{"*.csv"}}
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	finishTestWorkflow(t, r, wf.Name, time.Second)
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) == nil {
+		t.Fatal("Did not expect the pod to be deleted before the upload")
+	}
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	wf.Status.S3Upload = &st4sdv1alpha1.S3UploadStatus{State: st4sdv1alpha1.S3UploadSucceeded}
+	if err := r.Update(context.TODO(), wf); err != nil {
+		t.Fatal("Unable to update Workflow", err)
+	}
+
+	reconcileTestWorkflow(t, r, wf.Name)
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Expected the pod to be deleted after the upload")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -22,6 +23,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -38,6 +40,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
@@ -45,6 +48,46 @@ import (
 
 const workflowFinalizer = "workflow.finalizer.st4sd.ibm.com"
 
+// inlineInputsDir is where the primary pod mounts the inline files of spec.inputFiles
+const inlineInputsDir = "/tmp/inline-inputs"
+
+// mergedVariablesPath is where the merge-variables init-container stores the result of merging
+// the files in spec.variables (it lives in the emptyDir that the primary pod mounts under /tmp)
+const mergedVariablesPath = "/tmp/st4sd-variables/merged-variables.yaml"
+
+// mergeVariablesScript merges the variables files in its arguments (argv[2:]) in order and stores
+// the result in argv[1]. Later files override earlier ones. Nested dictionaries (e.g. global and
+// stages.$index) are merged key by key instead of being replaced.
+const mergeVariablesScript = `
+import os
+import sys
+
+import yaml
+
+
+def merge(dest, source):
+    for key, value in source.items():
+        if isinstance(value, dict) and isinstance(dest.get(key), dict):
+            merge(dest[key], value)
+        else:
+            dest[key] = value
+    return dest
+
+
+merged = {}
+for path in sys.argv[2:]:
+    with open(path, "r") as f:
+        contents = yaml.safe_load(f) or {}
+    if not isinstance(contents, dict):
+        raise ValueError("Variables file %s does not contain a dictionary" % path)
+    print("Merging variables from", path)
+    merge(merged, contents)
+
+os.makedirs(os.path.dirname(sys.argv[1]), exist_ok=True)
+with open(sys.argv[1], "w") as f:
+    yaml.safe_dump(merged, f)
+`
+
 // WorkflowReconciler reconciles a Workflow object
 type WorkflowReconciler struct {
 	client.Client
@@ -53,19 +96,75 @@ type WorkflowReconciler struct {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *WorkflowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &st4sdv1alpha1.Workflow{},
+		upstreamWorkflowsField, indexUpstreamWorkflows); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&st4sdv1alpha1.Workflow{}).
+		Owns(&corev1.Pod{}).
+		Watches(&st4sdv1alpha1.Workflow{}, handler.EnqueueRequestsFromMapFunc(r.downstreamWorkflows)).
 		Complete(r)
 }
 
-func (r *WorkflowReconciler) finalizeWorkflow(reqLogger logr.Logger, wf *st4sdv1alpha1.Workflow) error {
-	reqLogger.Info("Successfully finalized Workflow" + wf.ObjectMeta.Name)
-	return nil
+// finalizeWorkflow stops the orchestrator of a Workflow that is being deleted, waits for the primary pod
+// to terminate so that the monitoring side-container reports the final status, and then deletes the pods
+// and jobs that the orchestrator created. It returns false while waiting for the primary pod. After the finalizer
+// timeout it stops waiting and ignores errors.
+func (r *WorkflowReconciler) finalizeWorkflow(ctx context.Context, reqLogger logr.Logger,
+	wf *st4sdv1alpha1.Workflow) (bool, error) {
+	timeout := time.Duration(*getDefaultValues(r, wf.Namespace, configMapName()).FinalizerTimeoutSeconds) * time.Second
+	timedOut := time.Since(wf.GetDeletionTimestamp().Time) > timeout
+
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: primaryPodName(wf), Namespace: wf.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) && !timedOut {
+		return false, err
+	} else if err == nil && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		if timedOut {
+			reqLogger.Info("Timed out waiting for the primary pod to terminate", "Pod.Name", pod.Name,
+				"timeout", timeout)
+		} else {
+			if err := r.stopPod(ctx, reqLogger, pod); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+
+	pods, jobs, err := r.listChildResources(ctx, wf)
+	if err == nil {
+		_, _, err = r.deleteChildResources(ctx, reqLogger, pods, jobs)
+	}
+	if err != nil && !timedOut {
+		return false, err
+	} else if err != nil {
+		reqLogger.Error(err, "Unable to delete the pods and jobs of the Workflow")
+	}
+
+	reqLogger.Info("Successfully finalized Workflow " + wf.ObjectMeta.Name)
+	return true, nil
+}
+
+// workflowLabel returns the value of the "workflow" label of the pods of a Workflow
+func workflowLabel(cr *st4sdv1alpha1.Workflow) string {
+	if value, ok := cr.ObjectMeta.Labels["workflow"]; ok {
+		return value
+	}
+	return cr.Name
 }
 
 //+kubebuilder:rbac:groups=st4sd.ibm.com,resources=workflows,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=st4sd.ibm.com,resources=workflows/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=st4sd.ibm.com,resources=workflows/finalizers,verbs=update
+//+kubebuilder:rbac:groups=st4sd.ibm.com,resources=workflowdefaults;clusterworkflowdefaults,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
@@ -99,14 +198,17 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			// Run finalization logic for workflowFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeWorkflow(reqLogger, instance); err != nil {
+			finalized, err := r.finalizeWorkflow(ctx, reqLogger, instance)
+			if err != nil {
 				return ctrl.Result{}, err
+			} else if !finalized {
+				return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 			}
 
 			// Remove workflowFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
 			controllerutil.RemoveFinalizer(instance, workflowFinalizer)
-			err := r.Update(ctx, instance)
+			err = r.Update(ctx, instance)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -114,29 +216,139 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, nil
 	}
 
-	// VV: Remove comments to add finalizer for Workflow objects
-	/*
-		 if !controllerutil.ContainsFinalizer(instance, workflowFinalizer) {
-			controllerutil.AddFinalizer(instance, workflowFinalizer)
-			err = r.Update(ctx, instance)
-			if err != nil {
-			 return ctrl.Result{}, err
-			}
-		 }*/
+	if !controllerutil.ContainsFinalizer(instance, workflowFinalizer) {
+		controllerutil.AddFinalizer(instance, workflowFinalizer)
+		err = r.Update(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if token := instance.Annotations[rerunAnnotation]; token != "" && token != instance.Status.ObservedRerun {
+		rerun, err := r.rerunWorkflow(ctx, reqLogger, instance, token)
+		if err != nil {
+			return ctrl.Result{}, err
+		} else if !rerun {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
+	if instance.Spec.S3BucketInput != nil && len(instance.Spec.S3BucketInput.Objects) > 0 {
+		if err := r.reconcileS3Fetch(ctx, reqLogger, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if instance.Spec.S3BucketOutput != nil {
+		if err := r.reconcileS3Upload(ctx, reqLogger, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if instance.Spec.Cancel && !instance.Status.Completed() {
+		return ctrl.Result{}, r.cancelWorkflow(ctx, reqLogger, instance)
+	}
+
+	if instance.Spec.Suspend && (instance.Status.Phase == st4sdv1alpha1.WorkflowSuspended ||
+		!instance.Status.Completed()) {
+		return ctrl.Result{}, r.suspendWorkflow(ctx, reqLogger, instance)
+	} else if !instance.Spec.Suspend && instance.Status.Phase == st4sdv1alpha1.WorkflowSuspended {
+		resumed, err := r.resumeWorkflow(ctx, reqLogger, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		} else if !resumed {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
+	if instance.Spec.RetryPolicy != nil && !instance.Spec.Cancel && !instance.Spec.Suspend &&
+		instance.Status.Phase != st4sdv1alpha1.WorkflowSucceeded &&
+		instance.Status.Phase != st4sdv1alpha1.WorkflowCancelled {
+		requeueAfter, err := r.reconcileRetry(ctx, reqLogger, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		} else if requeueAfter > 0 {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+	}
 
-	if len(instance.Status.Updated) != 0 {
+	if len(instance.Status.Updated) != 0 || instance.Status.Completed() {
 		/* reqLogger.Info("Workflow status has already been updated - this workflow has already " +
 		"executed in the past, will not create a new pod.") */
-		return ctrl.Result{}, nil
+		if err := r.syncPhase(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileChildResources(ctx, reqLogger, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		requeueAfter, err := r.reconcileTTL(ctx, reqLogger, instance)
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
+	}
+
+	// VV: Do not start the workflow before the Workflows it depends on complete. Once the pod exists,
+	// changes to those Workflows do not matter anymore
+	if instance.Status.InstanceName == "" {
+		completed := len(instance.Status.CompletedDependencies)
+		message, failed, err := r.checkDependencies(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if failed {
+			reqLogger.Info("Workflow cannot start", "reason", message)
+			return ctrl.Result{}, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed,
+				st4sdv1alpha1.ReasonDependencyFailed, message)
+		} else if message != "" {
+			if len(instance.Status.CompletedDependencies) != completed {
+				// VV: setPhase skips the update if just status.completedDependencies changed
+				instance.Status.Phase = st4sdv1alpha1.WorkflowWaiting
+				instance.Status.Reason = st4sdv1alpha1.ReasonWaitingForDependencies
+				instance.Status.Message = message
+				return ctrl.Result{}, r.Client.Update(ctx, instance)
+			}
+			return ctrl.Result{}, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowWaiting,
+				st4sdv1alpha1.ReasonWaitingForDependencies, message)
+		}
+	}
+
+	if instance.Spec.RestartFrom != nil && instance.Status.Restart == nil {
+		message, err := r.applyRestartFrom(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		} else if message != "" {
+			return ctrl.Result{}, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed,
+				st4sdv1alpha1.ReasonInvalidRestart, message)
+		}
 	}
 
 	// Define a new Pod object
 	pod, err := newPodForCR(r, instance)
 
-	if err != nil {
+	if instance.Spec.Hold {
+		held, err := r.holdWorkflow(ctx, reqLogger, instance, pod, err)
+		if err != nil || held {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if reason := podErrorReason(err); reason != "" && instance.Status.InstanceName != "" {
+		// VV: The pod exists, e.g. a Workflow it reads inputs from was deleted after the pod started
+		reqLogger.Info("Unable to regenerate the primary pod", "reason", reason, "error", err.Error())
+		if err := r.syncPhase(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.reconcileChildResources(ctx, reqLogger, instance)
+	} else if reason != "" {
+		return ctrl.Result{}, r.setPhase(ctx, instance, st4sdv1alpha1.WorkflowFailed, reason, err.Error())
+	} else if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if admitted, err := r.admitWorkflow(ctx, instance, pod); err != nil {
+		return ctrl.Result{}, err
+	} else if !admitted {
+		return ctrl.Result{RequeueAfter: admissionRequeueDelay}, nil
+	}
+
 	// Set Workflow instance as the owner and controller
 	if err := controllerutil.SetControllerReference(instance, pod, r.Scheme); err != nil {
 		return ctrl.Result{}, err
@@ -152,6 +364,16 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			instance.ObjectMeta.Labels = make(map[string]string)
 		}
 
+		instance.Status.InstanceName = workflowInstanceName(instance)
+		instance.Status.Phase = st4sdv1alpha1.WorkflowRunning
+		instance.Status.Reason = ""
+		instance.Status.Message = ""
+		if kueueGated(pod) {
+			instance.Status.Phase = st4sdv1alpha1.WorkflowQueued
+			instance.Status.Reason = st4sdv1alpha1.ReasonWaitingForKueue
+			instance.Status.Message = kueueMessage(instance)
+		}
+
 		err := r.Client.Update(context.TODO(), instance)
 		if err != nil {
 			return ctrl.Result{}, err
@@ -209,9 +431,20 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
-	// Pod already exists - don't requeue
+	requeueAfter, err := r.enforcePackageFetchTimeout(ctx, reqLogger, instance, found)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Pod already exists - don't requeue unless waiting for a timeout
 	// reqLogger.Info("Skip reconcile: Pod already exists", "Pod.Namespace", found.Namespace, "Pod.Name", found.Name)
-	return ctrl.Result{}, nil
+	if err := r.syncPhase(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileChildResources(ctx, reqLogger, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func newEvent(uid types.UID, namespace string, workflowname string, name string) *corev1.Event {
@@ -242,6 +475,38 @@ func newEvent(uid types.UID, namespace string, workflowname string, name string)
 	}
 }
 
+// newWorkflowEvent returns an Event with a random name about a Workflow
+func newWorkflowEvent(cr *st4sdv1alpha1.Workflow, eventType string, reason string, message string) *corev1.Event {
+	//TODO ignoring the errors for the time being
+	randomHex, _ := randomHex(2)
+
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "workflow-event-" + randomHex,
+			Namespace: cr.Namespace,
+		},
+		Action: reason,
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Workflow",
+			Namespace:  cr.Namespace,
+			Name:       cr.Name,
+			UID:        cr.UID,
+			APIVersion: "st4sd.ibm.com/v1alpha1",
+		},
+		Type:           eventType,
+		EventTime:      metav1.NowMicro(),
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Source: corev1.EventSource{
+			Component: "workflow-controller",
+		},
+		ReportingInstance:   "workflow-controller-instance",
+		Reason:              reason,
+		ReportingController: "workflow-controller-controller",
+		Message:             message,
+	}
+}
+
 func contains(list []string, s string) bool {
 	for _, v := range list {
 		if v == s {
@@ -269,23 +534,198 @@ func remove(list []string, s string) []string {
 }
 
 func newConfigMap(cr *st4sdv1alpha1.Workflow, config string) *corev1.ConfigMap {
+	// TODO VV: We could change this to `st4sd-k8s-conf.yaml` but that would break backwards compatibility with
+	// existing workflow instances.
+	data := map[string]string{
+		"flow-k8s-conf.yml": config,
+	}
+
+	for i, v := range cr.Spec.InputFiles {
+		if v.Source == st4sdv1alpha1.InputFileSourceInline {
+			data[inlineInputKey(i)] = v.Content
+		}
+	}
 
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cr.Name + "-config",
 			Namespace: cr.Namespace,
 		},
-		// TODO VV: We could change this to `st4sd-k8s-conf.yaml` but that would break backwards compatibility with
-		// existing workflow instances.
-		Data: map[string]string{
-			"flow-k8s-conf.yml": config,
-		},
+		Data: data,
 	}
 }
 
-func getDefaultValues(r *WorkflowReconciler, namespace string, configmap_name string) *st4sdv1alpha1.DefaultWorkflowOptions {
-	logger := log.Log.WithName("getDefaultValues")
+// inlineInputKey returns the key of the <name>-config ConfigMap that holds the contents of spec.inputFiles[index]
+func inlineInputKey(index int) string {
+	return fmt.Sprintf("inline-input-%d", index)
+}
+
+// packageError is an invalid spec.package or spec.instance
+type packageError struct {
+	message string
+}
+
+func (e *packageError) Error() string {
+	return e.message
+}
+
+func newPackageError(format string, args ...interface{}) error {
+	return &packageError{message: fmt.Sprintf(format, args...)}
+}
+
+// orchestratorOptionsError is an entry of spec.additionalOptions which repeats an option that
+// spec.orchestratorOptions sets
+type orchestratorOptionsError struct {
+	option string
+}
+
+func (e *orchestratorOptionsError) Error() string {
+	return fmt.Sprintf("spec.additionalOptions contains %s which is already set by spec.orchestratorOptions", e.option)
+}
+
+// inputFileError is an invalid entry of spec.inputFiles
+type inputFileError struct {
+	index   int
+	message string
+}
+
+func (e *inputFileError) Error() string {
+	return fmt.Sprintf("spec.inputFiles[%d] %s", e.index, e.message)
+}
+
+func newInputFileError(index int, format string, args ...interface{}) error {
+	return &inputFileError{index: index, message: fmt.Sprintf(format, args...)}
+}
+
+// podErrorReason returns the reason for failing a Workflow whose primary pod the operator cannot
+// generate because of err, or an empty string if err is nil or retrying may succeed
+func podErrorReason(err error) string {
+	var templateErr *podTemplateError
+	var resourcesErr *resourcesError
+	var profileErr *resourceProfileError
+	var optionsErr *orchestratorOptionsError
+	var inputFileErr *inputFileError
+	var packageErr *packageError
+	var workflowInputErr *workflowInputError
+
+	switch {
+	case goerrors.As(err, &templateErr):
+		return st4sdv1alpha1.ReasonInvalidPodTemplate
+	case goerrors.As(err, &resourcesErr):
+		return st4sdv1alpha1.ReasonInvalidResources
+	case goerrors.As(err, &profileErr):
+		return st4sdv1alpha1.ReasonUnknownResourceProfile
+	case goerrors.As(err, &optionsErr):
+		return st4sdv1alpha1.ReasonInvalidOrchestratorOptions
+	case goerrors.As(err, &inputFileErr):
+		return st4sdv1alpha1.ReasonInvalidInputFiles
+	case goerrors.As(err, &packageErr):
+		return st4sdv1alpha1.ReasonInvalidPackage
+	case goerrors.As(err, &workflowInputErr):
+		return st4sdv1alpha1.ReasonDependencyFailed
+	}
+	return ""
+}
+
+// inputFilesToPaths converts spec.inputFiles to absolute paths in the $sourcePath[:$targetName] format of spec.inputs
+// The workflowOutputs map contains the paths of the entries with a fromWorkflow field (see resolveWorkflowOutputs)
+func inputFilesToPaths(cr *st4sdv1alpha1.Workflow, rootDirInputData string, rootDirS3InputData string,
+	workflowOutputs map[int]string) ([]string, error) {
+	ret := []string{}
+
+	for i, v := range cr.Spec.InputFiles {
+		f := v
+
+		if f.FromWorkflow != nil {
+			f.Source = st4sdv1alpha1.InputFileSourceWorkflow
+		}
+
+		switch f.Source {
+		case st4sdv1alpha1.InputFileSourceWorkflow:
+			resolved, ok := workflowOutputs[i]
+			if !ok {
+				return nil, newInputFileError(i, "does not reference the output of a Workflow")
+			}
+			f.Path = resolved
+		case st4sdv1alpha1.InputFileSourceVolume, "":
+			if !filepath.IsAbs(f.Path) {
+				f.Path = path.Join(rootDirInputData, f.Path)
+			}
+		case st4sdv1alpha1.InputFileSourceS3:
+			if cr.Spec.S3BucketInput == nil {
+				return nil, newInputFileError(i, "has source s3 but spec.s3BucketInput is unset")
+			}
+			f.Path = path.Join(rootDirS3InputData, "input", f.Path)
+		case st4sdv1alpha1.InputFileSourceInline:
+			if f.Path != path.Base(f.Path) || f.Path == "." || f.Path == ".." {
+				return nil, newInputFileError(i, "has source inline but its path is not a file name")
+			}
+			f.Path = path.Join(inlineInputsDir, f.Path)
+		default:
+			return nil, newInputFileError(i, "has unknown source %s", f.Source)
+		}
+
+		ret = append(ret, f.String())
+	}
+
+	return ret, nil
+}
+
+// primaryPodName returns the name of the current primary pod of a Workflow. Pods of reruns have the
+// suffix -run-$N and pods that replace failed ones have the suffix -attempt-$N
+func primaryPodName(cr *st4sdv1alpha1.Workflow) string {
+	name := cr.Name
+	if cr.Status.Run > 0 {
+		name = fmt.Sprintf("%s-run-%d", name, cr.Status.Run)
+	}
+	if len(cr.Status.Attempts) > 0 {
+		name = fmt.Sprintf("%s-attempt-%d", name, len(cr.Status.Attempts))
+	}
+	return name
+}
+
+// workflowInstanceName returns the name of the instance directory of a Workflow in its working volume.
+// Workflows that restart an instance directory reuse it, the rest get a new one named after their
+// primary pod unless spec.env sets INSTANCE_DIR_NAME
+func workflowInstanceName(cr *st4sdv1alpha1.Workflow) string {
+	if cr.Status.Restart != nil {
+		return cr.Status.Restart.InstanceName
+	} else if cr.Spec.Instance != "" {
+		return cr.Spec.Instance
+	}
+
+	for _, v := range cr.Spec.Env {
+		if v.Name == "INSTANCE_DIR_NAME" && v.Value != "" {
+			return v.Value
+		}
+	}
+	return primaryPodName(cr) + ".instance"
+}
 
+// createdInstanceName returns the name of the instance directory of a Workflow if it exists, i.e. the
+// Workflow restarts an instance directory or its orchestrator has reported its state, and an empty
+// string otherwise
+func createdInstanceName(cr *st4sdv1alpha1.Workflow) string {
+	if cr.Status.Restart != nil {
+		return cr.Status.Restart.InstanceName
+	} else if cr.Spec.Instance != "" {
+		return cr.Spec.Instance
+	} else if cr.Status.Experimentstate != "" {
+		return cr.Status.InstanceName
+	}
+	return ""
+}
+
+// configMapName returns the name of the ConfigMap with the default options (env-var CONFIGMAP_NAME),
+// the default is "st4sd-runtime-service"
+func configMapName() string {
+	if cm_name := os.Getenv("CONFIGMAP_NAME"); cm_name != "" {
+		return cm_name
+	}
+	return "st4sd-runtime-service"
+}
+
+func getDefaultValues(r *WorkflowReconciler, namespace string, configmap_name string) *st4sdv1alpha1.DefaultWorkflowOptions {
 	var options = st4sdv1alpha1.DefaultWorkflowOptions{}
 
 	// VV: Extract information from environment variables but then override that
@@ -293,8 +733,47 @@ func getDefaultValues(r *WorkflowReconciler, namespace string, configmap_name st
 	options.GitSyncImage = os.Getenv("GIT_SYNC_IMAGE")
 	options.WorkflowMonitoringImage = os.Getenv("WORKFLOW_MONITORING_IMAGE")
 	options.S3FetchFilesImage = os.Getenv("S3_FETCH_FILES_IMAGE")
+	options.S3UploadFilesImage = os.Getenv("S3_UPLOAD_FILES_IMAGE")
 	options.FlowImage = os.Getenv("FLOW_IMAGE")
 
+	finalizerTimeout := int32(900)
+	if timeout, err := strconv.ParseInt(os.Getenv("FINALIZER_TIMEOUT_SECONDS"), 10, 32); err == nil && timeout >= 0 {
+		finalizerTimeout = int32(timeout)
+	}
+	options.FinalizerTimeoutSeconds = &finalizerTimeout
+
+	if ttl, err := strconv.ParseInt(os.Getenv("TTL_SECONDS_AFTER_FINISHED"), 10, 32); err == nil && ttl >= 0 {
+		ttlSeconds := int32(ttl)
+		options.TTLSecondsAfterFinished = &ttlSeconds
+	}
+
+	if limit, err := strconv.ParseInt(os.Getenv("MAX_CONCURRENT_WORKFLOWS"), 10, 32); err == nil && limit >= 0 {
+		maxWorkflows := int32(limit)
+		options.MaxConcurrentWorkflows = &maxWorkflows
+	}
+	if quantity, err := resource.ParseQuantity(os.Getenv("MAX_CONCURRENT_CPU")); err == nil {
+		options.MaxConcurrentCPU = &quantity
+	}
+	if quantity, err := resource.ParseQuantity(os.Getenv("MAX_CONCURRENT_MEMORY")); err == nil {
+		options.MaxConcurrentMemory = &quantity
+	}
+
+	options.FairShareLabel = os.Getenv("FAIR_SHARE_LABEL")
+
+	// VV: The sources of default values, from the lowest to the highest precedence, are the environment
+	// variables, the ClusterWorkflowDefaults, the ConfigMap, and the WorkflowDefaults of the namespace
+	applyClusterWorkflowDefaults(r, &options)
+	applyConfigMapDefaults(r, namespace, configmap_name, &options)
+	applyNamespaceWorkflowDefaults(r, namespace, &options)
+
+	return &options
+}
+
+// applyConfigMapDefaults overrides options with the values in the config.json of a ConfigMap
+func applyConfigMapDefaults(r *WorkflowReconciler, namespace string, configmap_name string,
+	options *st4sdv1alpha1.DefaultWorkflowOptions) {
+	logger := log.Log.WithName("getDefaultValues")
+
 	// VV: Get the consumable-computing-config ConfigMap and
 	// try to extract default options from config.json
 	configMap := corev1.ConfigMap{}
@@ -302,14 +781,14 @@ func getDefaultValues(r *WorkflowReconciler, namespace string, configmap_name st
 
 	if err != nil {
 		logger.Info("Could not find consumable-computing-config ConfigMap", "err", err)
-		return &options
+		return
 	}
 
 	configJSONStr, ok := configMap.Data["config.json"]
 
 	if ok == false {
 		logger.Info("Could not find config.json in consumable-computing-config ConfigMap", "err", err)
-		return &options
+		return
 	}
 
 	configJSONbytes := []byte(configJSONStr)
@@ -337,16 +816,151 @@ func getDefaultValues(r *WorkflowReconciler, namespace string, configmap_name st
 		options.S3FetchFilesImage = config.S3FetchFilesImage
 	}
 
+	if len(config.S3UploadFilesImage) > 0 {
+		options.S3UploadFilesImage = config.S3UploadFilesImage
+	}
+
+	if config.FinalizerTimeoutSeconds != nil && *config.FinalizerTimeoutSeconds >= 0 {
+		options.FinalizerTimeoutSeconds = config.FinalizerTimeoutSeconds
+	}
+
+	if config.TTLSecondsAfterFinished != nil {
+		options.TTLSecondsAfterFinished = config.TTLSecondsAfterFinished
+	}
+
+	if config.MaxConcurrentWorkflows != nil && *config.MaxConcurrentWorkflows >= 0 {
+		options.MaxConcurrentWorkflows = config.MaxConcurrentWorkflows
+	}
+
+	if len(config.MaxConcurrentCPU) > 0 {
+		if quantity, err := resource.ParseQuantity(config.MaxConcurrentCPU); err == nil {
+			options.MaxConcurrentCPU = &quantity
+		} else {
+			logger.Info("Invalid max-concurrent-cpu", "value", config.MaxConcurrentCPU, "err", err)
+		}
+	}
+
+	if len(config.MaxConcurrentMemory) > 0 {
+		if quantity, err := resource.ParseQuantity(config.MaxConcurrentMemory); err == nil {
+			options.MaxConcurrentMemory = &quantity
+		} else {
+			logger.Info("Invalid max-concurrent-memory", "value", config.MaxConcurrentMemory, "err", err)
+		}
+	}
+
+	if len(config.FairShareLabel) > 0 {
+		options.FairShareLabel = config.FairShareLabel
+	}
+
 	if len(config.WorkflowMonitoringImage) > 0 {
 		options.WorkflowMonitoringImage = config.WorkflowMonitoringImage
 	}
 
-	options.GitSecret = config.GitSecret
-	options.GitSecretOAuth = config.GitSecretOAuth
-	options.WorkingVolume = config.WorkingVolume
-	options.ImagePullSecrets = config.ImagePullSecrets
+	// VV: The remaining keys have the same meaning as the fields of WorkflowDefaults
+	applyWorkflowDefaults(options, &st4sdv1alpha1.WorkflowDefaultsSpec{
+		ResourceProfiles:          config.Profiles,
+		NodeSelector:              config.NodeSelector,
+		Tolerations:               config.Tolerations,
+		Affinity:                  config.Affinity,
+		TopologySpreadConstraints: config.TopologySpreadConstraints,
+		PriorityClassName:         config.PriorityClassName,
+		RuntimeClassName:          config.RuntimeClassName,
+		GitSecret:                 config.GitSecret,
+		GitSecretOAuth:            config.GitSecretOAuth,
+		WorkingVolume:             config.WorkingVolume,
+		ImagePullSecrets:          config.ImagePullSecrets,
+	})
+}
+
+// s3BucketEnvVars returns the environment variables that the S3 tools expect for the credentials and
+// location of a bucket. The values come from the Secret of the Datashim dataset (if set) or the bucket info
+func s3BucketEnvVars(bucket *st4sdv1alpha1.DatashimS3BucketInfo) []corev1.EnvVar {
+	if bucket.Dataset != "" {
+		s3Keys := []struct{ envName, keyName string }{
+			{"S3_ACCESS_KEY_ID", "accessKeyID"},
+			{"S3_SECRET_ACCESS_KEY", "secretAccessKey"},
+			{"S3_ENDPOINT", "endpoint"},
+			{"S3_BUCKET", "bucket"},
+			{"S3_REGION", "region"},
+		}
 
-	return &options
+		envVars := []corev1.EnvVar{}
+		for _, v := range s3Keys {
+			envVars = append(envVars,
+				corev1.EnvVar{
+					Name: v.envName,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: bucket.Dataset,
+							}, Key: v.keyName}}})
+		}
+		return envVars
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:      "S3_ACCESS_KEY_ID",
+			Value:     bucket.AccessKeyID.Value,
+			ValueFrom: bucket.AccessKeyID.ValueFrom,
+		}, {
+			Name:      "S3_SECRET_ACCESS_KEY",
+			Value:     bucket.SecretAccessKey.Value,
+			ValueFrom: bucket.SecretAccessKey.ValueFrom,
+		}, {
+			Name:      "S3_ENDPOINT",
+			Value:     bucket.Endpoint.Value,
+			ValueFrom: bucket.Endpoint.ValueFrom,
+		}, {
+			Name:      "S3_BUCKET",
+			Value:     bucket.Bucket.Value,
+			ValueFrom: bucket.Bucket.ValueFrom,
+		}, {
+			Name:      "S3_REGION",
+			Value:     bucket.Region.Value,
+			ValueFrom: bucket.Region.ValueFrom,
+		},
+	}
+}
+
+// podSecurityContext returns the security context of the pods that the operator creates.
+// It uses the securityContext of the default values, or else the USER_ID, GROUP_ID (default 0),
+// and FSGROUP (default 5000) environment variables.
+func podSecurityContext(options *st4sdv1alpha1.DefaultWorkflowOptions) *corev1.PodSecurityContext {
+	if options.SecurityContext != nil {
+		return options.SecurityContext.DeepCopy()
+	}
+
+	var rootuser, _ = strconv.ParseInt(os.Getenv("USER_ID"), 10, 64)
+
+	// VV: Parse FSGROUP and use 5000 if nothing is provided
+	// (PODS can read/write to PesistentVolumeClaim-folders using gid 5000)
+	fsgroup := int64(5000)
+	if t, ok := os.LookupEnv("FSGROUP"); ok {
+		fsgroup, _ = strconv.ParseInt(t, 10, 64)
+	}
+
+	// VV: Parse GROU_ID and use 0 if nothing is provided
+	// (best practices expect that PODS can read/write to image-folders using gid 0)
+	group_id := int64(0)
+	if t, ok := os.LookupEnv("GROUP_ID"); ok {
+		group_id, _ = strconv.ParseInt(t, 10, 64)
+	}
+
+	return &corev1.PodSecurityContext{
+		FSGroup:    &fsgroup,
+		RunAsUser:  &rootuser,
+		RunAsGroup: &group_id,
+	}
+}
+
+// serviceAccountName returns the ServiceAccount of the pods that the operator creates.
+// The default name is "workflow-operator", override it using the env-var SERVICE_ACCOUNT_NAME
+func serviceAccountName() string {
+	if t, ok := os.LookupEnv("SERVICE_ACCOUNT_NAME"); ok {
+		return t
+	}
+	return "workflow-operator"
 }
 
 func rewrite_absolute_paths(paths []string, new_root string) []string {
@@ -367,12 +981,11 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	fmt.Println(cr)
 
 	var user, _ = strconv.ParseInt(os.Getenv("USER_ID"), 10, 64)
-	var configmap_name = "st4sd-runtime-service"
-	if cm_name := os.Getenv("CONFIGMAP_NAME"); cm_name != "" {
-		configmap_name = cm_name
-	}
 
-	var options = getDefaultValues(r, cr.ObjectMeta.Namespace, configmap_name)
+	var options = getDefaultValues(r, cr.ObjectMeta.Namespace, configMapName())
+	if options.SecurityContext != nil && options.SecurityContext.RunAsUser != nil {
+		user = *options.SecurityContext.RunAsUser
+	}
 
 	type WorkflowSourceType string
 	const (
@@ -400,15 +1013,21 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 
 		if len(cr.Spec.Package.FromConfigMap) > 0 {
 			if packageSource != WorkflowSourceUnknown {
-				return nil, fmt.Errorf("spec.package.fromConfigMap set but package is already configured as %s", packageSource)
+				return nil, newPackageError("spec.package.fromConfigMap set but package is already configured as %s", packageSource)
 			}
 			packageSource = WorkflowSourcePackageConfigMap
 		}
 	}
 
-	if len(cr.Spec.Instance) > 0 {
+	// VV: Workflows that restart from a previous run use the instance directory of that run instead
+	// of spec.package and spec.instance
+	instanceName := cr.Spec.Instance
+	if cr.Status.Restart != nil {
+		instanceName = cr.Status.Restart.InstanceName
+		packageSource = WorkflowSourceInstance
+	} else if len(cr.Spec.Instance) > 0 {
 		if packageSource != WorkflowSourceUnknown {
-			return nil, fmt.Errorf("spec.instance set but spec.package is set too (these fields are " +
+			return nil, newPackageError("spec.instance set but spec.package is set too (these fields are " +
 				"mutually exclusive)")
 		}
 
@@ -416,7 +1035,7 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	}
 
 	if packageSource == WorkflowSourceUnknown {
-		return nil, fmt.Errorf("workflow object does not have a proper populated spec.package/instance")
+		return nil, newPackageError("workflow object does not have a proper populated spec.package/instance")
 	}
 
 	// VV: Peek at the Workflow description and fill in the blanks
@@ -461,6 +1080,17 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 			options.WorkingVolume)
 	}
 
+	// VV: spec.env overrides the default environment variables with the same name
+	for _, env := range options.Env {
+		found := false
+		for _, v := range cr.Spec.Env {
+			found = found || v.Name == env.Name
+		}
+		if !found {
+			cr.Spec.Env = append(cr.Spec.Env, env)
+		}
+	}
+
 	// VV: Hard-coded variables go here (FIXME figure out how to deprecate those)
 	workdir := "/tmp/workdir"
 	rootDirConfigMapInputData := "/tmp/inputdir"
@@ -469,20 +1099,6 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	datadir := rootDirConfigMapInputData
 	variabledir := rootDirConfigMapInputData
 
-	// VV: Parse FSGROUP and use 5000 if nothing is provided
-	// (PODS can read/write to PesistentVolumeClaim-folders using gid 5000)
-	fsgroup := int64(5000)
-	if t, ok := os.LookupEnv("FSGROUP"); ok {
-		fsgroup, _ = strconv.ParseInt(t, 10, 64)
-	}
-
-	// VV: Parse GROU_ID and use 0 if nothing is provided
-	// (best practices expect that PODS can read/write to image-folders using gid 0)
-	group_id := int64(0)
-	if t, ok := os.LookupEnv("GROUP_ID"); ok {
-		group_id, _ = strconv.ParseInt(t, 10, 64)
-	}
-
 	labels := map[string]string{
 		"workflow": cr.Name,
 		"rest-uid": fmt.Sprint(cr.UID),
@@ -517,6 +1133,17 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	cr.Spec.Variables = rewrite_absolute_paths(cr.Spec.Variables, variabledir)
 	cr.Spec.Data = rewrite_absolute_paths(cr.Spec.Data, datadir)
 
+	// VV: Structured input files have an explicit source, so they never get rebased to inputdir
+	workflowOutputs, err := resolveWorkflowOutputs(r, cr)
+	if err != nil {
+		return nil, err
+	}
+	structuredInputs, err := inputFilesToPaths(cr, rootDirConfigMapInputData, rootDirS3InputData, workflowOutputs)
+	if err != nil {
+		return nil, err
+	}
+	inputs := append(append([]string{}, cr.Spec.Inputs...), structuredInputs...)
+
 	// VV: At this point, the workflow object has been migrated to latest Spec
 	volumes := make([]v1.Volume, len(cr.Spec.Volumes))
 	copy(volumes, cr.Spec.Volumes)
@@ -538,12 +1165,19 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	envVars := []corev1.EnvVar{}
 
 	for _, v := range cr.Spec.Env {
+		if v.Name == "INSTANCE_DIR_NAME" {
+			continue
+		}
 		envVars = append(envVars, corev1.EnvVar{
 			Name:      v.Name,
 			Value:     v.Value,
 			ValueFrom: v.ValueFrom,
 		})
 	}
+	// VV: The operator picks the name of the instance directory so that it knows where the outputs are
+	envVars = append(envVars, corev1.EnvVar{
+		Name:  "INSTANCE_DIR_NAME",
+		Value: workflowInstanceName(cr)})
 
 	configVolumeMount := corev1.VolumeMount{
 		Name: "config-volume",
@@ -555,6 +1189,31 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 
 	volumeMountsPrimary = append(volumeMountsPrimary, configVolumeMount)
 
+	inlineInputItems := []corev1.KeyToPath{}
+	for i, v := range cr.Spec.InputFiles {
+		if v.Source == st4sdv1alpha1.InputFileSourceInline {
+			inlineInputItems = append(inlineInputItems, corev1.KeyToPath{Key: inlineInputKey(i), Path: v.Path})
+		}
+	}
+
+	if len(inlineInputItems) > 0 {
+		volumes = append(volumes, corev1.Volume{
+			Name: "inline-inputs",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: cr.Name + "-config",
+					},
+					Items: inlineInputItems,
+				},
+			},
+		})
+		volumeMountsPrimary = append(volumeMountsPrimary, corev1.VolumeMount{
+			Name:      "inline-inputs",
+			MountPath: inlineInputsDir,
+		})
+	}
+
 	volumes = append(volumes, cr.Spec.WorkingVolume)
 	volumeMountsPrimary = append(volumeMountsPrimary, corev1.VolumeMount{
 		Name:      cr.Spec.WorkingVolume.Name,
@@ -620,10 +1279,19 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	}
 	volumeMountsPrimary = append(volumeMountsPrimary, tempVolumeMountPrimary)
 
-	// Resources for the init containers
-	downloadPackageResources := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("100m"),
-		corev1.ResourceMemory: resource.MustParse("200Mi"),
+	// Resources for the containers
+	resources, err := containerResources(cr, options)
+	if err != nil {
+		return nil, err
+	}
+
+	packageFetchResources, err := resourceRequirements(resources.GitFetch)
+	if err != nil {
+		return nil, newResourcesError("gitFetch: %v", err)
+	}
+	s3FetchResources, err := resourceRequirements(resources.S3Fetch)
+	if err != nil {
+		return nil, newResourcesError("s3Fetch: %v", err)
 	}
 
 	// There will be one initContainer downloading the package
@@ -691,7 +1359,7 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 		} else if packageSource == WorkflowSourcePackageHTTPS {
 			u, err := url.Parse(cr.Spec.Package.URL)
 			if err != nil {
-				return nil, err
+				return nil, newPackageError("invalid spec.package.url: %v", err)
 			}
 			gitRoot := strings.Split(u.Path[1:], "/")[1]
 
@@ -744,22 +1412,10 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 				})
 		}
 
-		if cr.Spec.Resources != nil && cr.Spec.Resources.GitFetch != nil {
-			if len(cr.Spec.Resources.GitFetch.Cpu) > 0 {
-				downloadPackageResources[corev1.ResourceCPU] = resource.MustParse(cr.Spec.Resources.GitFetch.Cpu)
-			}
-			if len(cr.Spec.Resources.GitFetch.Memory) > 0 {
-				downloadPackageResources[corev1.ResourceMemory] = resource.MustParse(cr.Spec.Resources.GitFetch.Memory)
-			}
-		}
-
 		initContainerPackage = corev1.Container{
-			Name:  "git-sync-package",
-			Image: options.GitSyncImage,
-			Resources: corev1.ResourceRequirements{
-				Limits:   downloadPackageResources,
-				Requests: downloadPackageResources,
-			},
+			Name:            "git-sync-package",
+			Image:           options.GitSyncImage,
+			Resources:       packageFetchResources,
 			ImagePullPolicy: corev1.PullAlways,
 			Args:            gitCloneOptions,
 			SecurityContext: &corev1.SecurityContext{
@@ -829,11 +1485,8 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 			Env:             s3PackageDownloadEnvVars,
 			ImagePullPolicy: corev1.PullAlways,
 			VolumeMounts:    volumeMountsS3PackageDownloadContainers,
-			Resources: corev1.ResourceRequirements{
-				Limits:   downloadPackageResources,
-				Requests: downloadPackageResources,
-			},
-			WorkingDir: "/workdir",
+			Resources:       packageFetchResources,
+			WorkingDir:      "/workdir",
 			SecurityContext: &corev1.SecurityContext{
 				RunAsUser:  &user,
 				RunAsGroup: &user,
@@ -841,18 +1494,9 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 		}
 	}
 
-	wfMonitorResources := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("100m"),
-		corev1.ResourceMemory: resource.MustParse("200Mi"),
-	}
-
-	if cr.Spec.Resources != nil && cr.Spec.Resources.Monitor != nil {
-		if len(cr.Spec.Resources.Monitor.Cpu) > 0 {
-			wfMonitorResources[corev1.ResourceCPU] = resource.MustParse(cr.Spec.Resources.Monitor.Cpu)
-		}
-		if len(cr.Spec.Resources.Monitor.Memory) > 0 {
-			wfMonitorResources[corev1.ResourceMemory] = resource.MustParse(cr.Spec.Resources.Monitor.Memory)
-		}
+	wfMonitorResources, err := resourceRequirements(resources.Monitor)
+	if err != nil {
+		return nil, newResourcesError("monitor: %v", err)
 	}
 
 	volumeMountsMonitor := []corev1.VolumeMount{
@@ -887,10 +1531,7 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 				},
 			},
 		},
-		Resources: corev1.ResourceRequirements{
-			Limits:   wfMonitorResources,
-			Requests: wfMonitorResources,
-		},
+		Resources:       wfMonitorResources,
 		ImagePullPolicy: corev1.PullAlways,
 		VolumeMounts:    volumeMountsMonitor,
 	}
@@ -900,17 +1541,49 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 		command = []string{cr.Spec.Command}
 	}
 
-	for _, v := range cr.Spec.Inputs {
+	for _, v := range inputs {
 		command = append(command, "-i", v)
 	}
-	for _, v := range cr.Spec.Variables {
-		command = append(command, "-a", v)
+	// VV: elaunch.py accepts a single variables file, multiple files get merged by an init-container
+	if len(cr.Spec.Variables) == 1 {
+		command = append(command, "-a", cr.Spec.Variables[0])
+	} else if len(cr.Spec.Variables) > 1 {
+		command = append(command, "-a", mergedVariablesPath)
 	}
 	for _, v := range cr.Spec.Data {
 		command = append(command, "-d", v)
 	}
 
-	command = append(command, cr.Spec.AdditionalOptions...)
+	orchestratorOptions := cr.Spec.OrchestratorOptions
+	additionalOptions := cr.Spec.AdditionalOptions
+	if cr.Status.Restart != nil {
+		restartOptions := st4sdv1alpha1.OrchestratorOptions{}
+		if orchestratorOptions != nil {
+			restartOptions = *orchestratorOptions
+		}
+		stage := cr.Status.Restart.Stage
+		restartOptions.RestartFromStage = &stage
+		orchestratorOptions = &restartOptions
+
+		additionalOptions = []string{}
+		for _, v := range cr.Spec.AdditionalOptions {
+			if st4sdv1alpha1.OptionName(v) != "--restart" {
+				additionalOptions = append(additionalOptions, v)
+			}
+		}
+	}
+
+	orchestratorArgs := orchestratorOptions.ToArgs()
+	for _, v := range additionalOptions {
+		for _, typed := range orchestratorArgs {
+			if st4sdv1alpha1.OptionName(v) == st4sdv1alpha1.OptionName(typed) {
+				return nil, &orchestratorOptionsError{option: st4sdv1alpha1.OptionName(v)}
+			}
+		}
+	}
+
+	command = append(command, orchestratorArgs...)
+	command = append(command, additionalOptions...)
 
 	fullPath := ""
 
@@ -920,16 +1593,15 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 	} else if packageSource == WorkflowSourcePackageConfigMap {
 		fullPath = path.Join(packageMount, "lambda.package")
 	} else if packageSource == WorkflowSourceInstance {
-		fullPath = path.Join(workdir, cr.Spec.Instance)
-		// VV: Automatically generate the INSTANCE_DIR_NAME env variable
-		envVars = append(envVars, corev1.EnvVar{
-			Name:  "INSTANCE_DIR_NAME",
-			Value: cr.Spec.Instance})
+		fullPath = path.Join(workdir, instanceName)
 	} else if packageSource == WorkflowSourcePackageS3 {
 		fullPath = path.Join(packageMount, path.Base(cr.Spec.Package.FromPath))
 	}
 
-	if len(cr.Spec.Package.WithManifest) > 0 {
+	// VV: The instance directory already contains the workflow definition
+	usesPackage := cr.Spec.Package != nil && packageSource != WorkflowSourceInstance
+
+	if usesPackage && len(cr.Spec.Package.WithManifest) > 0 {
 		if fullPath == "" || filepath.IsAbs(cr.Spec.Package.WithManifest) {
 			command = append(command, "--manifest", cr.Spec.Package.WithManifest)
 		} else {
@@ -937,7 +1609,7 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 		}
 	}
 
-	if len(cr.Spec.Package.FromPath) > 0 && packageSource != WorkflowSourcePackageS3 {
+	if usesPackage && len(cr.Spec.Package.FromPath) > 0 && packageSource != WorkflowSourcePackageS3 {
 		fromPath := cr.Spec.Package.FromPath
 		if fullPath == "" || filepath.IsAbs(fromPath) {
 			fullPath = fromPath
@@ -948,18 +1620,9 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 
 	command = append(command, fullPath)
 
-	elaunchResources := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("1000m"),
-		corev1.ResourceMemory: resource.MustParse("500Mi"),
-	}
-
-	if cr.Spec.Resources != nil && cr.Spec.Resources.ElaunchPrimary != nil {
-		if len(cr.Spec.Resources.ElaunchPrimary.Cpu) > 0 {
-			elaunchResources[corev1.ResourceCPU] = resource.MustParse(cr.Spec.Resources.ElaunchPrimary.Cpu)
-		}
-		if len(cr.Spec.Resources.ElaunchPrimary.Memory) > 0 {
-			elaunchResources[corev1.ResourceMemory] = resource.MustParse(cr.Spec.Resources.ElaunchPrimary.Memory)
-		}
+	elaunchResources, err := resourceRequirements(resources.ElaunchPrimary)
+	if err != nil {
+		return nil, newResourcesError("elaunchPrimary: %v", err)
 	}
 
 	if packageSource == WorkflowSourcePackageHTTPS ||
@@ -987,59 +1650,14 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 
 		s3FetchCommand := []string{"st4sd-fetch-files.sh"}
 		s3EnvVars := []corev1.EnvVar{{Name: "ROOT_OUTPUT", Value: rootDirS3InputData}}
-
-		if cr.Spec.S3BucketInput.Dataset != "" {
-			s3Keys := map[string]string{
-				"S3_ACCESS_KEY_ID":     "accessKeyID",
-				"S3_SECRET_ACCESS_KEY": "secretAccessKey",
-				"S3_ENDPOINT":          "endpoint",
-				"S3_BUCKET":            "bucket",
-				"S3_REGION":            "region",
-			}
-
-			for envName, keyName := range s3Keys {
-				s3EnvVars = append(s3EnvVars,
-					corev1.EnvVar{
-						Name: envName,
-						ValueFrom: &corev1.EnvVarSource{
-							SecretKeyRef: &corev1.SecretKeySelector{
-								LocalObjectReference: corev1.LocalObjectReference{
-									Name: cr.Spec.S3BucketInput.Dataset,
-								}, Key: keyName}}})
-			}
-		} else {
-			s3EnvVars = append(s3EnvVars,
-				corev1.EnvVar{
-					Name:      "S3_ACCESS_KEY_ID",
-					Value:     cr.Spec.S3BucketInput.AccessKeyID.Value,
-					ValueFrom: cr.Spec.S3BucketInput.AccessKeyID.ValueFrom,
-				}, corev1.EnvVar{
-					Name:      "S3_SECRET_ACCESS_KEY",
-					Value:     cr.Spec.S3BucketInput.SecretAccessKey.Value,
-					ValueFrom: cr.Spec.S3BucketInput.SecretAccessKey.ValueFrom,
-				}, corev1.EnvVar{
-					Name:      "S3_ENDPOINT",
-					Value:     cr.Spec.S3BucketInput.Endpoint.Value,
-					ValueFrom: cr.Spec.S3BucketInput.Endpoint.ValueFrom,
-				}, corev1.EnvVar{
-					Name:      "S3_BUCKET",
-					Value:     cr.Spec.S3BucketInput.Bucket.Value,
-					ValueFrom: cr.Spec.S3BucketInput.Bucket.ValueFrom,
-				},
-				corev1.EnvVar{
-					Name:      "S3_REGION",
-					Value:     cr.Spec.S3BucketInput.Region.Value,
-					ValueFrom: cr.Spec.S3BucketInput.Region.ValueFrom,
-				},
-			)
-		}
+		s3EnvVars = append(s3EnvVars, s3BucketEnvVars(&cr.Spec.S3BucketInput.DatashimS3BucketInfo)...)
 
 		// VV: Input/Data files which are expected to be retrieved via s3
 		// are expected to be stored under the rootDirS3InputData folder
 		s3_dir_input := rootDirS3InputData + "/input/"
 		s3_dir_data := rootDirS3InputData + "/data/"
 
-		for _, v := range cr.Spec.Inputs {
+		for _, v := range inputs {
 			if strings.HasPrefix(v, s3_dir_input) {
 				s3_path := v[len(s3_dir_input):]
 				complex := st4sdv1alpha1.SplitPathToSourcePathAndTargetName(s3_path)
@@ -1062,11 +1680,8 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 			Env:             s3EnvVars,
 			ImagePullPolicy: corev1.PullAlways,
 			VolumeMounts:    volumeMountsS3FetchFiles,
-			Resources: corev1.ResourceRequirements{
-				Limits:   downloadPackageResources,
-				Requests: downloadPackageResources,
-			},
-			WorkingDir: "/workdir",
+			Resources:       s3FetchResources,
+			WorkingDir:      "/workdir",
 			SecurityContext: &corev1.SecurityContext{
 				RunAsUser:  &user,
 				RunAsGroup: &user,
@@ -1075,22 +1690,49 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 
 		initcontainers = append(initcontainers, s3FetchFiles)
 
+		if len(cr.Spec.S3BucketInput.Objects) > 0 {
+			s3FetchObjects, err := newS3FetchObjectsContainer(cr, s3FetchFilesImage, rootDirS3InputData,
+				volumeMountsS3FetchFiles, s3FetchFiles.Resources, s3FetchFiles.SecurityContext)
+			if err != nil {
+				return nil, err
+			}
+			initcontainers = append(initcontainers, s3FetchObjects)
+		}
+
 		volumes = append(volumes, corev1.Volume{
 			Name:         "s3-fetch",
 			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
 	}
 
+	if len(cr.Spec.Variables) > 1 {
+		// VV: The init-container can read the variables files from the same volumes as the orchestrator
+		// and stores the merged file in the emptyDir that the orchestrator mounts under /tmp
+		volumeMountsMergeVariables := make([]corev1.VolumeMount, len(volumeMountsPrimary))
+		copy(volumeMountsMergeVariables, volumeMountsPrimary)
+
+		mergeVariables := corev1.Container{
+			Name:            "merge-variables",
+			Image:           cr.Spec.Image,
+			Command:         []string{"python3", "-c", mergeVariablesScript, mergedVariablesPath},
+			Args:            cr.Spec.Variables,
+			Env:             envVars,
+			ImagePullPolicy: corev1.PullAlways,
+			VolumeMounts:    volumeMountsMergeVariables,
+			Resources:       packageFetchResources,
+			WorkingDir:      workdir,
+		}
+
+		initcontainers = append(initcontainers, mergeVariables)
+	}
+
 	primarycontainer := corev1.Container{
 		Name:            "elaunch-primary",
 		Image:           cr.Spec.Image,
 		Env:             envVars,
 		ImagePullPolicy: corev1.PullAlways,
 		VolumeMounts:    volumeMountsPrimary,
-		Resources: corev1.ResourceRequirements{
-			Limits:   elaunchResources,
-			Requests: elaunchResources,
-		},
-		WorkingDir: workdir,
+		Resources:       elaunchResources,
+		WorkingDir:      workdir,
 	}
 	// this is a hack to just display what the arguments would be without running anything
 	if cr.Spec.Debug == true {
@@ -1108,46 +1750,49 @@ func newPodForCR(r *WorkflowReconciler, cr *st4sdv1alpha1.Workflow) (*corev1.Pod
 		imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{Name: v})
 	}
 
-	var rootuser, _ = strconv.ParseInt(os.Getenv("USER_ID"), 10, 64)
-
 	var terminationSeconds int64
 	terminationSeconds = 600
-
-	// VV: Default name of ServiceAccount is "workflow-operator" but override it using the
-	// env-var SERVICE_ACCOUNT_NAME
-	serviceAccountName := "workflow-operator"
-	if t, ok := os.LookupEnv("SERVICE_ACCOUNT_NAME"); ok {
-		serviceAccountName = t
+	if cr.Spec.TerminationGracePeriodSeconds != nil {
+		terminationSeconds = *cr.Spec.TerminationGracePeriodSeconds
 	}
 
-	// reqLogger.Info("checking service account " + serviceAccountName)
-
 	var podSpec = corev1.PodSpec{
 		//giving the same permissions to the pod
 		//uses a pre-created one
-		ServiceAccountName:            serviceAccountName,
+		ServiceAccountName:            serviceAccountName(),
 		RestartPolicy:                 corev1.RestartPolicyNever,
 		Volumes:                       volumes,
 		TerminationGracePeriodSeconds: &terminationSeconds,
 		Containers:                    containers,
 		InitContainers:                initcontainers,
-		SecurityContext: &corev1.PodSecurityContext{
-			FSGroup:    &fsgroup,
-			RunAsUser:  &rootuser,
-			RunAsGroup: &group_id,
-		},
+		SecurityContext:               podSecurityContext(options),
 	}
 	if len(imagePullSecrets) != 0 {
 		podSpec.ImagePullSecrets = imagePullSecrets
 	}
+	applySchedulingDefaults(cr, options)
+	podSpec.NodeSelector = cr.Spec.NodeSelector
+	podSpec.Tolerations = cr.Spec.Tolerations
+	podSpec.Affinity = cr.Spec.Affinity
+	podSpec.TopologySpreadConstraints = cr.Spec.TopologySpreadConstraints
+	podSpec.PriorityClassName = cr.Spec.PriorityClassName
+	podSpec.RuntimeClassName = cr.Spec.RuntimeClassName
+	if cr.Spec.Timeout != nil {
+		podSpec.ActiveDeadlineSeconds = activeDeadlineSeconds(cr.Spec.Timeout.Duration)
+	}
 	pod := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cr.Name,
+			Name:      primaryPodName(cr),
 			Namespace: cr.Namespace,
 			Labels:    labels,
 		},
 		Spec: podSpec,
 	}
+	applyKueue(cr, &pod)
+
+	if err := applyPodTemplate(cr, &pod); err != nil {
+		return nil, err
+	}
 
 	// podJSON, _ := json.Marshal(&pod)
 	// reqLogger.Info(string(podJSON))
@@ -0,0 +1,244 @@
+/*
+	Copyright IBM Inc. All Rights Reserved.
+
+	SPDX-License-Identifier: Apache-2.0
+
+	Authors:
+	  Vassilis Vassiliadis
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	st4sdv1alpha1 "github.com/st4sd/st4sd-runtime-k8s/api/v1alpha1"
+)
+
+func newTestReconciler(objects ...client.Object) *WorkflowReconciler {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = st4sdv1alpha1.AddToScheme(scheme)
+
+	return &WorkflowReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+			WithIndex(&st4sdv1alpha1.Workflow{}, upstreamWorkflowsField, indexUpstreamWorkflows).Build(),
+		Scheme: scheme,
+	}
+}
+
+func newTestWorkflow(name string) *st4sdv1alpha1.Workflow {
+	return &st4sdv1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: st4sdv1alpha1.WorkflowSpec{
+			Package: &st4sdv1alpha1.Gitrepo{
+				URL:    "https://github.com/st4sd/sum-numbers",
+				Branch: "main",
+			},
+			WorkingVolume: corev1.Volume{Name: "working-volume"},
+		},
+	}
+}
+
+func reconcileTestWorkflow(t *testing.T, r *WorkflowReconciler, name string) reconcile.Result {
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+	if err != nil {
+		t.Fatal("Unable to reconcile", name, err)
+	}
+	return result
+}
+
+func getTestWorkflow(t *testing.T, r *WorkflowReconciler, name string) *st4sdv1alpha1.Workflow {
+	wf := &st4sdv1alpha1.Workflow{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "default"}, wf); err != nil {
+		t.Fatal("Unable to get Workflow", name, err)
+	}
+	return wf
+}
+
+func getTestPod(r *WorkflowReconciler, name string) *corev1.Pod {
+	pod := &corev1.Pod{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "default"}, pod); err != nil {
+		return nil
+	}
+	return pod
+}
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+func countOption(command []string, option string) int {
+	count := 0
+	for _, v := range command {
+		if v == option {
+			count++
+		}
+	}
+	return count
+}
+
+// TestNewPodForCRSingleVariablesFile tests that a single variables file is forwarded to the orchestrator as is
+func TestNewPodForCRSingleVariablesFile(t *testing.T) {
+	wf := newTestWorkflow("single-variables")
+	wf.Spec.Variables = []string{"/tmp/inputdir/variables.yaml"}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	if findContainer(pod.Spec.InitContainers, "merge-variables") != nil {
+		t.Error("Did not expect a merge-variables init-container")
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if countOption(primary.Command, "-a") != 1 || !contains(primary.Command, "/tmp/inputdir/variables.yaml") {
+		t.Error("Expected exactly 1 variables file", "command", primary.Command)
+	}
+}
+
+// TestNewPodForCRMergeVariablesFiles tests that multiple variables files are merged into 1 in order
+func TestNewPodForCRMergeVariablesFiles(t *testing.T) {
+	wf := newTestWorkflow("merge-variables")
+	wf.Spec.Variables = []string{"/tmp/inputdir/site.yaml", "project.yaml", "/tmp/inputdir/run.yaml"}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	merge := findContainer(pod.Spec.InitContainers, "merge-variables")
+	if merge == nil {
+		t.Fatal("Expected a merge-variables init-container")
+	}
+
+	expected := []string{"/tmp/inputdir/site.yaml", "/tmp/inputdir/project.yaml", "/tmp/inputdir/run.yaml"}
+	if len(merge.Args) != len(expected) {
+		t.Fatal("Unexpected arguments to merge-variables", "actual", merge.Args, "expected", expected)
+	}
+	for i := range expected {
+		if merge.Args[i] != expected[i] {
+			t.Error("Unexpected arguments to merge-variables", "actual", merge.Args, "expected", expected)
+		}
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	if countOption(primary.Command, "-a") != 1 || !contains(primary.Command, mergedVariablesPath) {
+		t.Error("Expected orchestrator to receive the merged variables file", "command", primary.Command)
+	}
+}
+
+// TestNewPodForCROrchestratorOptions tests that typed orchestrator options are rendered before
+// additionalOptions and that the two cannot set the same option
+func TestNewPodForCROrchestratorOptions(t *testing.T) {
+	logLevel := int32(15)
+	wf := newTestWorkflow("orchestrator-options")
+	wf.Spec.OrchestratorOptions = &st4sdv1alpha1.OrchestratorOptions{Platform: "openshift", LogLevel: &logLevel}
+	wf.Spec.AdditionalOptions = []string{"--failSafeDelays=no"}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	for _, option := range []string{"--platform=openshift", "--log-level=15", "--failSafeDelays=no"} {
+		if !contains(primary.Command, option) {
+			t.Error("Missing option", option, "command", primary.Command)
+		}
+	}
+
+	wf = newTestWorkflow("orchestrator-options-conflict")
+	wf.Spec.OrchestratorOptions = &st4sdv1alpha1.OrchestratorOptions{Platform: "openshift"}
+	wf.Spec.AdditionalOptions = []string{"--platform=kubernetes"}
+
+	r := newTestReconciler(wf)
+	reconcileTestWorkflow(t, r, wf.Name)
+
+	wf = getTestWorkflow(t, r, wf.Name)
+	if wf.Status.Phase != st4sdv1alpha1.WorkflowFailed ||
+		wf.Status.Reason != st4sdv1alpha1.ReasonInvalidOrchestratorOptions {
+		t.Error("Expected --platform in both orchestratorOptions and additionalOptions to fail the Workflow",
+			"status", wf.Status)
+	}
+	if getTestPod(r, wf.Name) != nil {
+		t.Error("Did not expect a pod")
+	}
+}
+
+// TestNewPodForCRInputFiles tests that spec.inputFiles are converted to orchestrator arguments
+func TestNewPodForCRInputFiles(t *testing.T) {
+	wf := newTestWorkflow("input-files")
+	wf.Spec.S3BucketInput = &st4sdv1alpha1.S3BucketInputInfo{
+		DatashimS3BucketInfo: st4sdv1alpha1.DatashimS3BucketInfo{Dataset: "my-dataset"}}
+	wf.Spec.InputFiles = []st4sdv1alpha1.InputFile{
+		{Path: "/data/field.conf", Rename: "field:conf", Source: st4sdv1alpha1.InputFileSourceVolume},
+		{Path: "relative.conf", Source: st4sdv1alpha1.InputFileSourceVolume},
+		{Path: "molecules/smiles.csv", Source: st4sdv1alpha1.InputFileSourceS3},
+		{Path: "inline.txt", Source: st4sdv1alpha1.InputFileSourceInline, Content: "hello"},
+	}
+
+	pod, err := newPodForCR(newTestReconciler(), wf)
+	if err != nil {
+		t.Fatal("Unable to generate pod", err)
+	}
+
+	primary := findContainer(pod.Spec.Containers, "elaunch-primary")
+	for _, input := range []string{
+		"/data/field.conf:field\\:conf",
+		"/tmp/inputdir/relative.conf",
+		"/tmp/s3-root-dir/input/molecules/smiles.csv",
+		inlineInputsDir + "/inline.txt",
+	} {
+		if !contains(primary.Command, input) {
+			t.Error("Missing input", input, "command", primary.Command)
+		}
+	}
+
+	s3Fetch := findContainer(pod.Spec.InitContainers, "s3-fetch")
+	if s3Fetch == nil || !contains(s3Fetch.Args, "molecules/smiles.csv") {
+		t.Error("Expected s3-fetch to download molecules/smiles.csv")
+	}
+
+	configMap := newConfigMap(wf, "")
+	if configMap.Data[inlineInputKey(3)] != "hello" {
+		t.Error("Expected the contents of the inline file in the ConfigMap", "data", configMap.Data)
+	}
+
+	invalid := map[string]st4sdv1alpha1.InputFile{
+		"input-files-no-bucket":   {Path: "key", Source: st4sdv1alpha1.InputFileSourceS3},
+		"input-files-inline-path": {Path: "dir/inline.txt", Source: st4sdv1alpha1.InputFileSourceInline, Content: "hello"},
+	}
+	for name, inputFile := range invalid {
+		wf = newTestWorkflow(name)
+		wf.Spec.InputFiles = []st4sdv1alpha1.InputFile{inputFile}
+
+		r := newTestReconciler(wf)
+		reconcileTestWorkflow(t, r, wf.Name)
+
+		wf = getTestWorkflow(t, r, wf.Name)
+		if wf.Status.Phase != st4sdv1alpha1.WorkflowFailed || wf.Status.Reason != st4sdv1alpha1.ReasonInvalidInputFiles {
+			t.Error("Expected the Workflow to fail with InvalidInputFiles", "name", name, "status", wf.Status)
+		}
+		if getTestPod(r, wf.Name) != nil {
+			t.Error("Did not expect a pod", "name", name)
+		}
+	}
+}